@@ -5,9 +5,11 @@ import (
 	"flag"
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/server"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
 	"github.com/aakosarev/kanban-board/back/pkg/postgres"
 	"github.com/aakosarev/kanban-board/back/pkg/redis"
+	goredis "github.com/redis/go-redis/v9"
 	"log"
 	"time"
 )
@@ -24,24 +26,31 @@ func main() {
 	appLogger.InitLogger()
 	appLogger.WithName(server.GetMicroserviceName(cfg))
 
-	postgresConfig := postgres.NewPgConfig(
-		cfg.Postgres.Username,
-		cfg.Postgres.Password,
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.Database,
-	)
-
-	postgresClient, err := postgres.NewClient(context.Background(), 5, time.Second*5, postgresConfig)
-	if err != nil {
-		appLogger.Fatalf("Postgres init: %s", err)
-	} else {
-		appLogger.Info("Postgres connected")
+	var postgresClient postgres.Client
+	var redisClient *goredis.Client
+
+	// the memory driver keeps everything in process and needs no external services
+	if cfg.Storage.Driver != constants.Memory {
+		postgresConfig := postgres.NewPgConfig(
+			cfg.Postgres.Username,
+			cfg.Postgres.Password,
+			cfg.Postgres.Host,
+			cfg.Postgres.Port,
+			cfg.Postgres.Database,
+		)
+
+		postgresClient, err = postgres.NewClient(context.Background(), 5, time.Second*5, postgresConfig)
+		if err != nil {
+			appLogger.Fatalf("Postgres init: %s", err)
+		} else {
+			appLogger.Info("Postgres connected")
+		}
+		defer postgresClient.Close()
+
+		redisClient = redis.NewRedisClient(cfg)
+		defer redisClient.Close()
+		appLogger.Info("Redis connected")
 	}
 
-	redisClient := redis.NewRedisClient(cfg)
-	defer redisClient.Close()
-	appLogger.Info("Redis connected")
-
 	appLogger.Fatal(server.NewServer(cfg, appLogger, redisClient, postgresClient).Run())
 }
@@ -5,5 +5,13 @@ import "github.com/labstack/echo/v4"
 type Handlers interface {
 	Register() echo.HandlerFunc
 	Login() echo.HandlerFunc
+	LoginTOTP() echo.HandlerFunc
 	Logout() echo.HandlerFunc
+	UpdateLocale() echo.HandlerFunc
+	RequestPasswordReset() echo.HandlerFunc
+	ResetPassword() echo.HandlerFunc
+	VerifyEmail() echo.HandlerFunc
+	ResendVerification() echo.HandlerFunc
+	EnrollTOTP() echo.HandlerFunc
+	ConfirmTOTP() echo.HandlerFunc
 }
@@ -4,18 +4,24 @@ import (
 	"errors"
 	"github.com/aakosarev/kanban-board/back/internal/auth"
 	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/middleware"
 	"github.com/aakosarev/kanban-board/back/internal/models"
 	"github.com/aakosarev/kanban-board/back/internal/session"
-	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
 	"github.com/aakosarev/kanban-board/back/pkg/utils"
-	"github.com/go-playground/validator"
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"net/http"
 )
 
+const (
+	maxUserAgentLength = 512
+)
+
 type AuthHandlers struct {
 	group     *echo.Group
+	mw        *middleware.Manager
 	log       logger.Logger
 	cfg       *config.Config
 	v         *validator.Validate
@@ -25,13 +31,36 @@ type AuthHandlers struct {
 
 func NewAuthHandlers(
 	group *echo.Group,
+	mw *middleware.Manager,
 	log logger.Logger,
 	cfg *config.Config,
 	v *validator.Validate,
 	authUC auth.UseCase,
 	sessionUC session.UseCase,
 ) *AuthHandlers {
-	return &AuthHandlers{group: group, log: log, cfg: cfg, v: v, authUC: authUC, sessionUC: sessionUC}
+	return &AuthHandlers{group: group, mw: mw, log: log, cfg: cfg, v: v, authUC: authUC, sessionUC: sessionUC}
+}
+
+// createSession signs the user in on the client of the request.
+func (h *AuthHandlers) createSession(c echo.Context, userID int) error {
+	userAgent := c.Request().UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	sess, err := h.sessionUC.CreateSession(c.Request().Context(), &models.Session{
+		UserID:    userID,
+		IPAddress: utils.GetIPAddress(c),
+		UserAgent: userAgent,
+	}, h.cfg.Session.Expire)
+	if err != nil {
+		h.log.Errorf("(sessionUC.CreateSession) err: {%v}", err)
+		return err
+	}
+
+	c.SetCookie(utils.CreateSessionCookie(h.cfg, sess))
+
+	return nil
 }
 
 func (h *AuthHandlers) Signup() echo.HandlerFunc {
@@ -39,59 +68,57 @@ func (h *AuthHandlers) Signup() echo.HandlerFunc {
 		user := &models.User{}
 		if err := utils.ReadRequest(c, user); err != nil {
 			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
 		createdUser, err := h.authUC.Register(c.Request().Context(), user)
 		if err != nil {
 			h.log.Errorf("(authUC.Register) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
-		sess, err := h.sessionUC.CreateSession(c.Request().Context(), &models.Session{
-			UserID: createdUser.ID,
-		}, h.cfg.Session.Expire)
-		if err != nil {
-			h.log.Errorf("(sessionUC.CreateSession) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+		if err = h.createSession(c, createdUser.ID); err != nil {
+			return err
 		}
 
-		c.SetCookie(utils.CreateSessionCookie(h.cfg, sess))
-
 		return c.JSON(http.StatusCreated, createdUser)
 	}
 }
 
+// Login answers 202 with an MFA token instead of signing in users with TOTP enabled.
+// The token is exchanged for a session by LoginTOTP.
 func (h *AuthHandlers) Login() echo.HandlerFunc {
 	type Login struct {
 		Email    string `json:"email" validate:"omitempty,lte=60,email"`
 		Password string `json:"password,omitempty" validate:"required,gte=6"`
 	}
+	type MFARequired struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
 	return func(c echo.Context) error {
 		login := &Login{}
 		if err := utils.ReadRequest(c, login); err != nil {
 			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
-		foundUser, err := h.authUC.Login(c.Request().Context(), &models.User{
+		foundUser, mfaToken, err := h.authUC.Login(c.Request().Context(), &models.User{
 			Email:    login.Email,
 			Password: login.Password,
-		})
+		}, utils.GetIPAddress(c))
 		if err != nil {
 			h.log.Errorf("(authUC.Login) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
-		sess, err := h.sessionUC.CreateSession(c.Request().Context(), &models.Session{
-			UserID: foundUser.ID,
-		}, h.cfg.Session.Expire)
-		if err != nil {
-			h.log.Errorf("(sessionUC.CreateSession) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+		if mfaToken != "" {
+			return c.JSON(http.StatusAccepted, &MFARequired{MFARequired: true, MFAToken: mfaToken})
 		}
 
-		c.SetCookie(utils.CreateSessionCookie(h.cfg, sess))
+		if err = h.createSession(c, foundUser.ID); err != nil {
+			return err
+		}
 
 		return c.JSON(http.StatusOK, foundUser)
 	}
@@ -103,15 +130,278 @@ func (h *AuthHandlers) Logout() echo.HandlerFunc {
 		if err != nil {
 			if errors.Is(err, http.ErrNoCookie) {
 				h.log.Error(err)
-				return httpErrors.ErrorCtxResponse(c, httpErrors.Unauthorized, h.cfg.Http.DebugErrorsResponse)
+				return domainErrors.Unauthorized
 			}
 			h.log.Error(err)
-			return httpErrors.NewInternalServerError(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
 		if err := h.sessionUC.DeleteByID(c.Request().Context(), cookie.Value); err != nil {
 			h.log.Error(err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
+		}
+
+		utils.DeleteSessionCookie(c, h.cfg.Session.Name)
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+func (h *AuthHandlers) UpdateLocale() echo.HandlerFunc {
+	type Locale struct {
+		Locale string `json:"locale" validate:"required,oneof=en ru"`
+	}
+	return func(c echo.Context) error {
+		locale := &Locale{}
+		if err := utils.ReadRequest(c, locale); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		updatedUser, err := h.authUC.UpdateLocale(c.Request().Context(), user.ID, locale.Locale)
+		if err != nil {
+			h.log.Errorf("(authUC.UpdateLocale) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, updatedUser)
+	}
+}
+
+// RequestPasswordReset always accepts a valid email, whether it is registered or not.
+func (h *AuthHandlers) RequestPasswordReset() echo.HandlerFunc {
+	type ResetRequest struct {
+		Email string `json:"email" validate:"required,lte=60,email"`
+	}
+	return func(c echo.Context) error {
+		request := &ResetRequest{}
+		if err := utils.ReadRequest(c, request); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		if err := h.authUC.RequestPasswordReset(c.Request().Context(), request.Email); err != nil {
+			h.log.Errorf("(authUC.RequestPasswordReset) err: {%v}", err)
+			return err
+		}
+
+		return c.NoContent(http.StatusAccepted)
+	}
+}
+
+// ResetPassword signs the user out of every session, including the current one.
+func (h *AuthHandlers) ResetPassword() echo.HandlerFunc {
+	type Reset struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,gte=6"`
+	}
+	return func(c echo.Context) error {
+		reset := &Reset{}
+		if err := utils.ReadRequest(c, reset); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		userID, err := h.authUC.ResetPassword(c.Request().Context(), reset.Token, reset.Password)
+		if err != nil {
+			h.log.Errorf("(authUC.ResetPassword) err: {%v}", err)
+			return err
+		}
+
+		if err = h.sessionUC.DeleteByUserID(c.Request().Context(), userID); err != nil {
+			h.log.Errorf("(sessionUC.DeleteByUserID) err: {%v}", err)
+			return err
+		}
+
+		utils.DeleteSessionCookie(c, h.cfg.Session.Name)
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+func (h *AuthHandlers) VerifyEmail() echo.HandlerFunc {
+	type Verification struct {
+		Token string `json:"token" validate:"required"`
+	}
+	return func(c echo.Context) error {
+		verification := &Verification{}
+		if err := utils.ReadRequest(c, verification); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		user, err := h.authUC.VerifyEmail(c.Request().Context(), verification.Token)
+		if err != nil {
+			h.log.Errorf("(authUC.VerifyEmail) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, user)
+	}
+}
+
+func (h *AuthHandlers) ResendVerification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		if err = h.authUC.ResendVerification(c.Request().Context(), user.ID); err != nil {
+			h.log.Errorf("(authUC.ResendVerification) err: {%v}", err)
+			return err
+		}
+
+		return c.NoContent(http.StatusAccepted)
+	}
+}
+
+func (h *AuthHandlers) LoginTOTP() echo.HandlerFunc {
+	type LoginTOTP struct {
+		MFAToken string `json:"mfa_token" validate:"required"`
+		Code     string `json:"code" validate:"required,lte=20"`
+	}
+	return func(c echo.Context) error {
+		login := &LoginTOTP{}
+		if err := utils.ReadRequest(c, login); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		foundUser, err := h.authUC.LoginTOTP(c.Request().Context(), login.MFAToken, login.Code, utils.GetIPAddress(c))
+		if err != nil {
+			h.log.Errorf("(authUC.LoginTOTP) err: {%v}", err)
+			return err
+		}
+
+		if err = h.createSession(c, foundUser.ID); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, foundUser)
+	}
+}
+
+func (h *AuthHandlers) EnrollTOTP() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		enrollment, err := h.authUC.EnrollTOTP(c.Request().Context(), user.ID)
+		if err != nil {
+			h.log.Errorf("(authUC.EnrollTOTP) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+func (h *AuthHandlers) ConfirmTOTP() echo.HandlerFunc {
+	type Confirmation struct {
+		Code string `json:"code" validate:"required,lte=20"`
+	}
+	type RecoveryCodes struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	return func(c echo.Context) error {
+		confirmation := &Confirmation{}
+		if err := utils.ReadRequest(c, confirmation); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		codes, err := h.authUC.ConfirmTOTP(c.Request().Context(), user.ID, confirmation.Code)
+		if err != nil {
+			h.log.Errorf("(authUC.ConfirmTOTP) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, &RecoveryCodes{RecoveryCodes: codes})
+	}
+}
+
+func (h *AuthHandlers) GetSessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		current, err := utils.GetSessionFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetSessionFromCtx) err: {%v}", err)
+			return err
+		}
+
+		sessions, err := h.sessionUC.GetByUserID(c.Request().Context(), user.ID, current.ID)
+		if err != nil {
+			h.log.Errorf("(sessionUC.GetByUserID) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// RevokeSession also signs the client out when it revokes its own session.
+func (h *AuthHandlers) RevokeSession() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		current, err := utils.GetSessionFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetSessionFromCtx) err: {%v}", err)
+			return err
+		}
+
+		id := c.Param("session_id")
+		if err = h.sessionUC.DeleteUserSession(c.Request().Context(), user.ID, id); err != nil {
+			h.log.Errorf("(sessionUC.DeleteUserSession) err: {%v}", err)
+			return err
+		}
+
+		if id == current.ID {
+			utils.DeleteSessionCookie(c, h.cfg.Session.Name)
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// LogoutEverywhere signs the user out of every session, including the current one.
+func (h *AuthHandlers) LogoutEverywhere() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		if err = h.sessionUC.DeleteByUserID(c.Request().Context(), user.ID); err != nil {
+			h.log.Errorf("(sessionUC.DeleteByUserID) err: {%v}", err)
+			return err
 		}
 
 		utils.DeleteSessionCookie(c, h.cfg.Session.Name)
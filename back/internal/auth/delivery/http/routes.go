@@ -3,5 +3,16 @@ package http
 func (h *AuthHandlers) MapRoutes() {
 	h.group.POST("/signup", h.Signup())
 	h.group.POST("/login", h.Login())
+	h.group.POST("/login/totp", h.LoginTOTP())
 	h.group.POST("/logout", h.Logout())
+	h.group.POST("/logout/all", h.LogoutEverywhere(), h.mw.AuthSessionMiddleware)
+	h.group.GET("/sessions", h.GetSessions(), h.mw.AuthSessionMiddleware)
+	h.group.DELETE("/sessions/:session_id", h.RevokeSession(), h.mw.AuthSessionMiddleware)
+	h.group.POST("/password/reset", h.RequestPasswordReset())
+	h.group.POST("/password/reset/confirm", h.ResetPassword())
+	h.group.POST("/email/verify", h.VerifyEmail())
+	h.group.POST("/email/verify/resend", h.ResendVerification(), h.mw.AuthSessionMiddleware)
+	h.group.POST("/totp/enroll", h.EnrollTOTP(), h.mw.AuthSessionMiddleware)
+	h.group.POST("/totp/confirm", h.ConfirmTOTP(), h.mw.AuthSessionMiddleware)
+	h.group.PUT("/locale", h.UpdateLocale(), h.mw.AuthSessionMiddleware)
 }
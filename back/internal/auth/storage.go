@@ -3,10 +3,45 @@ package auth
 import (
 	"context"
 	"github.com/aakosarev/kanban-board/back/internal/models"
+	"time"
 )
 
 type Storage interface {
 	Register(ctx context.Context, user *models.User) (*models.User, error)
 	FindByID(ctx context.Context, userID int) (*models.User, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateLocale(ctx context.Context, userID int, locale string) (*models.User, error)
+	VerifyEmail(ctx context.Context, userID int) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int, password string) error
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+	// UseTOTPStep records the time step of an accepted TOTP code, so no code of that
+	// step or an earlier one is accepted again.
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+}
+
+// TokenStorage keeps single-use tokens by their hash. A user has at most one token of
+// each kind, so saving a token revokes the previous one.
+type TokenStorage interface {
+	SaveToken(ctx context.Context, kind string, hash string, userID int, expire int) error
+	TakeToken(ctx context.Context, kind string, hash string) (int, error)
+	// AllowToken reports whether a new token of the kind may be issued to the user. When
+	// it may, no other is allowed for the next interval seconds.
+	AllowToken(ctx context.Context, kind string, userID int, interval int) (bool, error)
+}
+
+// AttemptStorage counts failed logins by key, such as an email or an IP address, and
+// blocks keys for a while.
+type AttemptStorage interface {
+	// Fail counts a failure and returns the failures of the key. They are forgotten
+	// window seconds after the last one.
+	Fail(ctx context.Context, key string, window int) (int, error)
+	// Forgive takes back one failure counted by Fail, such as an attempt that was
+	// counted up front and then succeeded.
+	Forgive(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+	Block(ctx context.Context, key string, duration time.Duration) error
+	// Blocked returns how long the key is still blocked for, or 0.
+	Blocked(ctx context.Context, key string) (time.Duration, error)
 }
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	attemptPrefix = "login-attempt"
+)
+
+// forgiveScript decrements the failures without creating a key that has expired, and
+// drops the key once nothing is left.
+var forgiveScript = redis.NewScript(`
+if redis.call("DECR", KEYS[1]) <= 0 then
+	redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type attemptStorage struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewAttemptStorage(redisClient *redis.Client) auth.AttemptStorage {
+	return &attemptStorage{redisClient: redisClient, basePrefix: attemptPrefix}
+}
+
+func (s *attemptStorage) Fail(ctx context.Context, key string, window int) (int, error) {
+	failuresKey := s.createKey("failures", key)
+
+	pipe := s.redisClient.TxPipeline()
+	incr := pipe.Incr(ctx, failuresKey)
+	pipe.Expire(ctx, failuresKey, time.Second*time.Duration(window))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errors.Wrap(err, "attemptStorage.Fail.pipe.Exec")
+	}
+
+	return int(incr.Val()), nil
+}
+
+func (s *attemptStorage) Forgive(ctx context.Context, key string) error {
+	if err := forgiveScript.Run(ctx, s.redisClient, []string{s.createKey("failures", key)}).Err(); err != nil {
+		return errors.Wrap(err, "attemptStorage.Forgive.forgiveScript.Run")
+	}
+	return nil
+}
+
+func (s *attemptStorage) Reset(ctx context.Context, key string) error {
+	if err := s.redisClient.Del(ctx, s.createKey("failures", key), s.createKey("blocked", key)).Err(); err != nil {
+		return errors.Wrap(err, "attemptStorage.Reset.redisClient.Del")
+	}
+	return nil
+}
+
+func (s *attemptStorage) Block(ctx context.Context, key string, duration time.Duration) error {
+	if err := s.redisClient.Set(ctx, s.createKey("blocked", key), 1, duration).Err(); err != nil {
+		return errors.Wrap(err, "attemptStorage.Block.redisClient.Set")
+	}
+	return nil
+}
+
+func (s *attemptStorage) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.redisClient.PTTL(ctx, s.createKey("blocked", key)).Result()
+	if err != nil {
+		return 0, errors.Wrap(err, "attemptStorage.Blocked.redisClient.PTTL")
+	}
+
+	// negative values report a missing key or a key without a TTL
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *attemptStorage) createKey(kind string, key string) string {
+	return fmt.Sprintf("%s: %s: %s", s.basePrefix, kind, key)
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/memory"
+	"github.com/pkg/errors"
+)
+
+type memoryAuthStorage struct {
+	db *memory.DB
+}
+
+func NewMemoryAuthStorage(db *memory.DB) auth.Storage {
+	return &memoryAuthStorage{db: db}
+}
+
+func (s *memoryAuthStorage) Register(ctx context.Context, user *models.User) (*models.User, error) {
+	var u models.User
+	err := s.db.Do(ctx, func() error {
+		for _, existing := range s.db.Users {
+			if existing.Email == user.Email {
+				return errors.Wrapf(domainErrors.Conflict, "memoryAuthStorage.Register: email %s already exists", user.Email)
+			}
+		}
+		u = models.User{ID: s.db.NextID(memory.UserTable), Email: user.Email, Password: user.Password, Locale: user.Locale}
+		s.db.Users[u.ID] = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (s *memoryAuthStorage) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var u *models.User
+	err := s.db.Do(ctx, func() error {
+		for _, existing := range s.db.Users {
+			if existing.Email == email {
+				existing := existing
+				u = &existing
+				return nil
+			}
+		}
+		return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.FindByEmail")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (s *memoryAuthStorage) FindByID(ctx context.Context, userID int) (*models.User, error) {
+	var u models.User
+	err := s.db.Do(ctx, func() error {
+		var ok bool
+		if u, ok = s.db.Users[userID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.FindByID")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (s *memoryAuthStorage) UpdateLocale(ctx context.Context, userID int, locale string) (*models.User, error) {
+	var u models.User
+	err := s.db.Do(ctx, func() error {
+		var ok bool
+		if u, ok = s.db.Users[userID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.UpdateLocale")
+		}
+		u.Locale = locale
+		s.db.Users[userID] = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (s *memoryAuthStorage) VerifyEmail(ctx context.Context, userID int) (*models.User, error) {
+	var u models.User
+	err := s.db.Do(ctx, func() error {
+		var ok bool
+		if u, ok = s.db.Users[userID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.VerifyEmail")
+		}
+		u.EmailVerified = true
+		s.db.Users[userID] = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (s *memoryAuthStorage) UpdatePassword(ctx context.Context, userID int, password string) error {
+	return s.db.Do(ctx, func() error {
+		u, ok := s.db.Users[userID]
+		if !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.UpdatePassword")
+		}
+		u.Password = password
+		s.db.Users[userID] = u
+		return nil
+	})
+}
+
+// SetTOTPSecret fails with domainErrors.Conflict when TOTP is already enabled.
+func (s *memoryAuthStorage) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	return s.db.Do(ctx, func() error {
+		u, ok := s.db.Users[userID]
+		if !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.SetTOTPSecret")
+		}
+		if u.TOTPEnabled {
+			return errors.Wrap(domainErrors.Conflict, "memoryAuthStorage.SetTOTPSecret: TOTP is already enabled")
+		}
+		u.TOTPSecret = secret
+		s.db.Users[userID] = u
+		return nil
+	})
+}
+
+func (s *memoryAuthStorage) EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	return s.db.Do(ctx, func() error {
+		u, ok := s.db.Users[userID]
+		if !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.EnableTOTP")
+		}
+		u.TOTPEnabled = true
+		s.db.Users[userID] = u
+
+		for code := range s.db.RecoveryCodes {
+			if code.UserID == userID {
+				delete(s.db.RecoveryCodes, code)
+			}
+		}
+		for _, hash := range recoveryCodeHashes {
+			s.db.RecoveryCodes[memory.RecoveryCode{UserID: userID, CodeHash: hash}] = struct{}{}
+		}
+		return nil
+	})
+}
+
+// UseTOTPStep fails with domainErrors.Conflict when the step is not after the last one.
+func (s *memoryAuthStorage) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	return s.db.Do(ctx, func() error {
+		u, ok := s.db.Users[userID]
+		if !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.UseTOTPStep")
+		}
+		if step <= u.TOTPLastStep {
+			return errors.Wrap(domainErrors.Conflict, "memoryAuthStorage.UseTOTPStep: the step was already used")
+		}
+		u.TOTPLastStep = step
+		s.db.Users[userID] = u
+		return nil
+	})
+}
+
+func (s *memoryAuthStorage) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	return s.db.Do(ctx, func() error {
+		code := memory.RecoveryCode{UserID: userID, CodeHash: codeHash}
+		if _, ok := s.db.RecoveryCodes[code]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryAuthStorage.UseRecoveryCode")
+		}
+		delete(s.db.RecoveryCodes, code)
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	"sync"
+	"time"
+)
+
+type memoryFailures struct {
+	count    int
+	expireAt time.Time
+}
+
+type memoryAttemptStorage struct {
+	mu         sync.Mutex
+	failures   map[string]memoryFailures
+	blocked    map[string]time.Time
+	basePrefix string
+}
+
+func NewMemoryAttemptStorage() auth.AttemptStorage {
+	return &memoryAttemptStorage{failures: make(map[string]memoryFailures), blocked: make(map[string]time.Time), basePrefix: attemptPrefix}
+}
+
+func (s *memoryAttemptStorage) Fail(ctx context.Context, key string, window int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key = s.createKey(key)
+	mf := s.failures[key]
+	if time.Now().After(mf.expireAt) {
+		mf.count = 0
+	}
+	mf.count++
+	mf.expireAt = time.Now().Add(time.Second * time.Duration(window))
+	s.failures[key] = mf
+
+	return mf.count, nil
+}
+
+func (s *memoryAttemptStorage) Forgive(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key = s.createKey(key)
+	mf, ok := s.failures[key]
+	if !ok {
+		return nil
+	}
+	mf.count--
+	if mf.count <= 0 || time.Now().After(mf.expireAt) {
+		delete(s.failures, key)
+		return nil
+	}
+	s.failures[key] = mf
+
+	return nil
+}
+
+func (s *memoryAttemptStorage) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, s.createKey(key))
+	delete(s.blocked, s.createKey(key))
+
+	return nil
+}
+
+func (s *memoryAttemptStorage) Block(ctx context.Context, key string, duration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocked[s.createKey(key)] = time.Now().Add(duration)
+
+	return nil
+}
+
+func (s *memoryAttemptStorage) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key = s.createKey(key)
+	left := time.Until(s.blocked[key])
+	if left <= 0 {
+		delete(s.blocked, key)
+		return 0, nil
+	}
+
+	return left, nil
+}
+
+func (s *memoryAttemptStorage) createKey(key string) string {
+	return fmt.Sprintf("%s: %s", s.basePrefix, key)
+}
@@ -0,0 +1,24 @@
+package storage_test
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	authS "github.com/aakosarev/kanban-board/back/internal/auth/storage"
+	"github.com/aakosarev/kanban-board/back/internal/auth/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/memory"
+	"testing"
+)
+
+func TestMemoryAuthStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Fresh(func() auth.Storage {
+		return authS.NewMemoryAuthStorage(memory.NewDB())
+	}))
+}
+
+func TestMemoryTokenStorage(t *testing.T) {
+	storagetest.RunTokens(t, conformance.Fresh(authS.NewMemoryTokenStorage))
+}
+
+func TestMemoryAttemptStorage(t *testing.T) {
+	storagetest.RunAttempts(t, conformance.Fresh(authS.NewMemoryAttemptStorage))
+}
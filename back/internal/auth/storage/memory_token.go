@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+type memoryToken struct {
+	userID   int
+	expireAt time.Time
+}
+
+type memoryTokenStorage struct {
+	mu         sync.Mutex
+	tokens     map[string]memoryToken
+	users      map[string]string
+	throttles  map[string]time.Time
+	basePrefix string
+}
+
+func NewMemoryTokenStorage() auth.TokenStorage {
+	return &memoryTokenStorage{tokens: make(map[string]memoryToken), users: make(map[string]string), throttles: make(map[string]time.Time), basePrefix: tokenPrefix}
+}
+
+func (s *memoryTokenStorage) SaveToken(ctx context.Context, kind string, hash string, userID int, expire int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userKey := s.createUserKey(kind, userID)
+	if previous, ok := s.users[userKey]; ok {
+		delete(s.tokens, previous)
+	}
+
+	key := s.createKey(kind, hash)
+	s.tokens[key] = memoryToken{userID: userID, expireAt: time.Now().Add(time.Second * time.Duration(expire))}
+	s.users[userKey] = key
+
+	return nil
+}
+
+// TakeToken reports unknown, revoked and expired tokens with domainErrors.NotFound.
+func (s *memoryTokenStorage) TakeToken(ctx context.Context, kind string, hash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.createKey(kind, hash)
+	mt, ok := s.tokens[key]
+	delete(s.tokens, key)
+	if !ok || time.Now().After(mt.expireAt) {
+		return 0, errors.Wrap(domainErrors.NotFound, "memoryTokenStorage.TakeToken")
+	}
+
+	delete(s.users, s.createUserKey(kind, mt.userID))
+
+	return mt.userID, nil
+}
+
+func (s *memoryTokenStorage) AllowToken(ctx context.Context, kind string, userID int, interval int) (bool, error) {
+	if interval <= 0 {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.createThrottleKey(kind, userID)
+	if time.Now().Before(s.throttles[key]) {
+		return false, nil
+	}
+
+	s.throttles[key] = time.Now().Add(time.Second * time.Duration(interval))
+	return true, nil
+}
+
+func (s *memoryTokenStorage) createKey(kind string, hash string) string {
+	return fmt.Sprintf("%s: %s: %s", s.basePrefix, kind, hash)
+}
+
+func (s *memoryTokenStorage) createUserKey(kind string, userID int) string {
+	return fmt.Sprintf("%s: %s-user: %d", s.basePrefix, kind, userID)
+}
+
+func (s *memoryTokenStorage) createThrottleKey(kind string, userID int) string {
+	return fmt.Sprintf("%s: %s-throttle: %d", s.basePrefix, kind, userID)
+}
@@ -0,0 +1,19 @@
+package storage_test
+
+import (
+	authS "github.com/aakosarev/kanban-board/back/internal/auth/storage"
+	"github.com/aakosarev/kanban-board/back/internal/auth/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/redistest"
+	"testing"
+)
+
+// TestRedisTokenStorage runs only when REDIS_ADDR points to a Redis instance.
+func TestRedisTokenStorage(t *testing.T) {
+	storagetest.RunTokens(t, conformance.Shared(authS.NewTokenStorage(redistest.Client(t))))
+}
+
+// TestRedisAttemptStorage runs only when REDIS_ADDR points to a Redis instance.
+func TestRedisAttemptStorage(t *testing.T) {
+	storagetest.RunAttempts(t, conformance.Shared(authS.NewAttemptStorage(redistest.Client(t))))
+}
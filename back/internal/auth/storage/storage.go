@@ -4,16 +4,19 @@ import (
 	"context"
 	"github.com/aakosarev/kanban-board/back/internal/auth"
 	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/aakosarev/kanban-board/back/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
 )
 
 type AuthStorage struct {
 	log    logger.Logger
-	client *pgxpool.Pool
+	client postgres.Client
 }
 
-func NewAuthStorage(log logger.Logger, client *pgxpool.Pool) auth.Storage {
+func NewAuthStorage(log logger.Logger, client postgres.Client) auth.Storage {
 	return &AuthStorage{
 		log:    log,
 		client: client,
@@ -22,14 +25,14 @@ func NewAuthStorage(log logger.Logger, client *pgxpool.Pool) auth.Storage {
 
 func (s *AuthStorage) Register(ctx context.Context, user *models.User) (*models.User, error) {
 	query := `
-		INSERT INTO "user"(email, password)
-		VALUES ($1, $2)
-		RETURNING *;
+		INSERT INTO "user"(email, password, locale)
+		VALUES ($1, $2, $3)
+		RETURNING id, email, password, locale, email_verified, totp_enabled, totp_secret, totp_last_step;
 	`
 
 	u := &models.User{}
 
-	if err := s.client.QueryRow(ctx, query, user.Email, user.Password).Scan(&u.ID, &u.Email, &u.Password); err != nil {
+	if err := s.conn(ctx).QueryRow(ctx, query, user.Email, user.Password, user.Locale).Scan(&u.ID, &u.Email, &u.Password, &u.Locale, &u.EmailVerified, &u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep); err != nil {
 		return nil, err
 	}
 
@@ -38,14 +41,17 @@ func (s *AuthStorage) Register(ctx context.Context, user *models.User) (*models.
 
 func (s *AuthStorage) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT *
+		SELECT id, email, password, locale, email_verified, totp_enabled, totp_secret, totp_last_step
 		FROM "user"
 		WHERE email = $1;
 	`
 
 	u := &models.User{}
 
-	if err := s.client.QueryRow(ctx, query, email).Scan(&u.ID, &u.Email, &u.Password); err != nil {
+	if err := s.conn(ctx).QueryRow(ctx, query, email).Scan(&u.ID, &u.Email, &u.Password, &u.Locale, &u.EmailVerified, &u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "AuthStorage.FindByEmail.Scan")
+		}
 		return nil, err
 	}
 
@@ -54,16 +60,188 @@ func (s *AuthStorage) FindByEmail(ctx context.Context, email string) (*models.Us
 
 func (s *AuthStorage) FindByID(ctx context.Context, userID int) (*models.User, error) {
 	query := `
-		SELECT *
+		SELECT id, email, password, locale, email_verified, totp_enabled, totp_secret, totp_last_step
 		FROM "user"
 		WHERE id = $1;
 	`
 
 	u := &models.User{}
 
-	if err := s.client.QueryRow(ctx, query, userID).Scan(&u.ID, &u.Email, &u.Password); err != nil {
+	if err := s.conn(ctx).QueryRow(ctx, query, userID).Scan(&u.ID, &u.Email, &u.Password, &u.Locale, &u.EmailVerified, &u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "AuthStorage.FindByID.Scan")
+		}
 		return nil, err
 	}
 
 	return u, nil
 }
+
+func (s *AuthStorage) UpdateLocale(ctx context.Context, userID int, locale string) (*models.User, error) {
+	query := `
+		UPDATE "user"
+		SET locale = $1
+		WHERE id = $2
+		RETURNING id, email, password, locale, email_verified, totp_enabled, totp_secret, totp_last_step;
+	`
+
+	u := &models.User{}
+
+	if err := s.conn(ctx).QueryRow(ctx, query, locale, userID).Scan(&u.ID, &u.Email, &u.Password, &u.Locale, &u.EmailVerified, &u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "AuthStorage.UpdateLocale.Scan")
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (s *AuthStorage) VerifyEmail(ctx context.Context, userID int) (*models.User, error) {
+	query := `
+		UPDATE "user"
+		SET email_verified = TRUE
+		WHERE id = $1
+		RETURNING id, email, password, locale, email_verified, totp_enabled, totp_secret, totp_last_step;
+	`
+
+	u := &models.User{}
+
+	if err := s.conn(ctx).QueryRow(ctx, query, userID).Scan(&u.ID, &u.Email, &u.Password, &u.Locale, &u.EmailVerified, &u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "AuthStorage.VerifyEmail.Scan")
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (s *AuthStorage) UpdatePassword(ctx context.Context, userID int, password string) error {
+	query := `
+		UPDATE "user"
+		SET password = $1
+		WHERE id = $2;
+	`
+
+	res, err := s.conn(ctx).Exec(ctx, query, password, userID)
+	if err != nil {
+		return errors.Wrap(err, "AuthStorage.UpdatePassword.Exec")
+	}
+
+	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
+		return errors.Wrap(domainErrors.NotFound, "AuthStorage.UpdatePassword.rowsAffected")
+	}
+
+	return nil
+}
+
+// SetTOTPSecret stores the secret of an enrollment that is not confirmed yet. It
+// fails with domainErrors.Conflict when TOTP is already enabled.
+func (s *AuthStorage) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `
+		UPDATE "user"
+		SET totp_secret = $1
+		WHERE id = $2 AND NOT totp_enabled;
+	`
+
+	res, err := s.conn(ctx).Exec(ctx, query, secret, userID)
+	if err != nil {
+		return errors.Wrap(err, "AuthStorage.SetTOTPSecret.Exec")
+	}
+
+	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
+		if _, err = s.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		return errors.Wrap(domainErrors.Conflict, "AuthStorage.SetTOTPSecret: TOTP is already enabled")
+	}
+
+	return nil
+}
+
+// EnableTOTP enables TOTP and replaces the recovery codes of the user.
+func (s *AuthStorage) EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	query := `
+		UPDATE "user"
+		SET totp_enabled = TRUE
+		WHERE id = $1;
+	`
+
+	res, err := s.conn(ctx).Exec(ctx, query, userID)
+	if err != nil {
+		return errors.Wrap(err, "AuthStorage.EnableTOTP.Exec")
+	}
+
+	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
+		return errors.Wrap(domainErrors.NotFound, "AuthStorage.EnableTOTP.rowsAffected")
+	}
+
+	query = `
+		DELETE FROM "recovery_code"
+		WHERE user_id = $1;
+	`
+
+	if _, err = s.conn(ctx).Exec(ctx, query, userID); err != nil {
+		return errors.Wrap(err, "AuthStorage.EnableTOTP.Exec")
+	}
+
+	query = `
+		INSERT INTO "recovery_code"(user_id, code_hash)
+		SELECT $1, unnest($2::TEXT[]);
+	`
+
+	if _, err = s.conn(ctx).Exec(ctx, query, userID, recoveryCodeHashes); err != nil {
+		return errors.Wrap(err, "AuthStorage.EnableTOTP.Exec")
+	}
+
+	return nil
+}
+
+// UseRecoveryCode deletes the recovery code, failing with domainErrors.NotFound when
+// the user does not have it.
+func (s *AuthStorage) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	query := `
+		DELETE FROM "recovery_code"
+		WHERE user_id = $1 AND code_hash = $2;
+	`
+
+	res, err := s.conn(ctx).Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return errors.Wrap(err, "AuthStorage.UseRecoveryCode.Exec")
+	}
+
+	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
+		return errors.Wrap(domainErrors.NotFound, "AuthStorage.UseRecoveryCode.rowsAffected")
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code. It fails with
+// domainErrors.Conflict when a code of the same or a later step was accepted before.
+func (s *AuthStorage) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	query := `
+		UPDATE "user"
+		SET totp_last_step = $1
+		WHERE id = $2 AND totp_last_step < $1;
+	`
+
+	res, err := s.conn(ctx).Exec(ctx, query, step, userID)
+	if err != nil {
+		return errors.Wrap(err, "AuthStorage.UseTOTPStep.Exec")
+	}
+
+	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
+		if _, err = s.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		return errors.Wrap(domainErrors.Conflict, "AuthStorage.UseTOTPStep: the step was already used")
+	}
+
+	return nil
+}
+
+func (s *AuthStorage) conn(ctx context.Context) postgres.Querier {
+	return postgres.Conn(ctx, s.client)
+}
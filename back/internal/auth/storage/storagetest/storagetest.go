@@ -0,0 +1,451 @@
+// Package storagetest is a conformance suite for auth.Storage implementations.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+// NewUser returns a user with an email that is unique across runs, so the suites
+// can share a database that is not cleaned between tests.
+func NewUser() *models.User {
+	return &models.User{Email: fmt.Sprintf("%s@storagetest.local", uuid.New().String()), Password: "hashed-password", Locale: "en"}
+}
+
+// Run runs the suite against the storage returned by newStorage, which is called
+// once per test.
+func Run(t *testing.T, newStorage func(t *testing.T) auth.Storage) {
+	conformance.Run(t, newStorage, []conformance.Test[auth.Storage]{
+		{Name: "Register", Fn: testRegister},
+		{Name: "RegisterDuplicateEmail", Fn: testRegisterDuplicateEmail},
+		{Name: "FindByID", Fn: testFindByID},
+		{Name: "FindByEmail", Fn: testFindByEmail},
+		{Name: "UpdateLocale", Fn: testUpdateLocale},
+		{Name: "VerifyEmail", Fn: testVerifyEmail},
+		{Name: "UpdatePassword", Fn: testUpdatePassword},
+		{Name: "TOTP", Fn: testTOTP},
+		{Name: "NotFound", Fn: testNotFound},
+	}...)
+}
+
+func testRegister(ctx context.Context, t *testing.T, s auth.Storage) {
+	user := NewUser()
+
+	u, err := s.Register(ctx, user)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if u.ID == 0 || u.Email != user.Email || u.Password != user.Password || u.Locale != user.Locale {
+		t.Fatalf("Register = %+v, want %+v with an ID", u, user)
+	}
+}
+
+func testRegisterDuplicateEmail(ctx context.Context, t *testing.T, s auth.Storage) {
+	user := NewUser()
+
+	if _, err := s.Register(ctx, user); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := s.Register(ctx, user); err == nil {
+		t.Fatal("Register with a taken email succeeded")
+	}
+}
+
+func testFindByID(ctx context.Context, t *testing.T, s auth.Storage) {
+
+	u, err := s.Register(ctx, NewUser())
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	found, err := s.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if *found != *u {
+		t.Fatalf("FindByID = %+v, want %+v", found, u)
+	}
+}
+
+func testFindByEmail(ctx context.Context, t *testing.T, s auth.Storage) {
+
+	u, err := s.Register(ctx, NewUser())
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	found, err := s.FindByEmail(ctx, u.Email)
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if *found != *u {
+		t.Fatalf("FindByEmail = %+v, want %+v", found, u)
+	}
+}
+
+func testUpdateLocale(ctx context.Context, t *testing.T, s auth.Storage) {
+
+	u, err := s.Register(ctx, NewUser())
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	updated, err := s.UpdateLocale(ctx, u.ID, "ru")
+	if err != nil {
+		t.Fatalf("UpdateLocale: %v", err)
+	}
+	u.Locale = "ru"
+	if *updated != *u {
+		t.Fatalf("UpdateLocale = %+v, want %+v", updated, u)
+	}
+
+	found, err := s.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if *found != *u {
+		t.Fatalf("FindByID after UpdateLocale = %+v, want %+v", found, u)
+	}
+}
+
+func testVerifyEmail(ctx context.Context, t *testing.T, s auth.Storage) {
+
+	u, err := s.Register(ctx, NewUser())
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if u.EmailVerified {
+		t.Fatalf("Register = %+v, want an unverified email", u)
+	}
+
+	verified, err := s.VerifyEmail(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	u.EmailVerified = true
+	if *verified != *u {
+		t.Fatalf("VerifyEmail = %+v, want %+v", verified, u)
+	}
+
+	found, err := s.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if *found != *u {
+		t.Fatalf("FindByID after VerifyEmail = %+v, want %+v", found, u)
+	}
+}
+
+func testUpdatePassword(ctx context.Context, t *testing.T, s auth.Storage) {
+
+	u, err := s.Register(ctx, NewUser())
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err = s.UpdatePassword(ctx, u.ID, "new-hashed-password"); err != nil {
+		t.Fatalf("UpdatePassword: %v", err)
+	}
+
+	found, err := s.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if found.Password != "new-hashed-password" {
+		t.Fatalf("FindByID after UpdatePassword = %+v, want the new password", found)
+	}
+}
+
+func testTOTP(ctx context.Context, t *testing.T, s auth.Storage) {
+	hash := func(n int) string { return fmt.Sprintf("%064d", n) }
+
+	u, err := s.Register(ctx, NewUser())
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err = s.SetTOTPSecret(ctx, u.ID, "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatalf("SetTOTPSecret: %v", err)
+	}
+	found, err := s.FindByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if found.TOTPSecret != "JBSWY3DPEHPK3PXP" || found.TOTPEnabled {
+		t.Fatalf("FindByID after SetTOTPSecret = %+v, want a pending secret", found)
+	}
+
+	if err = s.EnableTOTP(ctx, u.ID, []string{hash(1), hash(2)}); err != nil {
+		t.Fatalf("EnableTOTP: %v", err)
+	}
+	if found, err = s.FindByID(ctx, u.ID); err != nil || !found.TOTPEnabled {
+		t.Fatalf("FindByID after EnableTOTP = %+v, %v, want TOTP enabled", found, err)
+	}
+	if err = s.SetTOTPSecret(ctx, u.ID, "KRSXG5CTMVRXEZLU"); !errors.Is(err, domainErrors.Conflict) {
+		t.Fatalf("SetTOTPSecret when enabled err = %v, want domainErrors.Conflict", err)
+	}
+
+	if err = s.UseRecoveryCode(ctx, u.ID, hash(1)); err != nil {
+		t.Fatalf("UseRecoveryCode: %v", err)
+	}
+	if err = s.UseRecoveryCode(ctx, u.ID, hash(1)); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("UseRecoveryCode of a used code err = %v, want domainErrors.NotFound", err)
+	}
+
+	if err = s.EnableTOTP(ctx, u.ID, []string{hash(3)}); err != nil {
+		t.Fatalf("EnableTOTP: %v", err)
+	}
+	if err = s.UseRecoveryCode(ctx, u.ID, hash(2)); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("UseRecoveryCode of a replaced code err = %v, want domainErrors.NotFound", err)
+	}
+	if err = s.UseRecoveryCode(ctx, u.ID, hash(3)); err != nil {
+		t.Fatalf("UseRecoveryCode: %v", err)
+	}
+
+	if err = s.UseTOTPStep(ctx, u.ID, 100); err != nil {
+		t.Fatalf("UseTOTPStep: %v", err)
+	}
+	for _, step := range []int64{100, 99} {
+		if err = s.UseTOTPStep(ctx, u.ID, step); !errors.Is(err, domainErrors.Conflict) {
+			t.Fatalf("UseTOTPStep of step %d after 100 err = %v, want domainErrors.Conflict", step, err)
+		}
+	}
+	if err = s.UseTOTPStep(ctx, u.ID, 101); err != nil {
+		t.Fatalf("UseTOTPStep: %v", err)
+	}
+	if found, err = s.FindByID(ctx, u.ID); err != nil || found.TOTPLastStep != 101 {
+		t.Fatalf("FindByID after UseTOTPStep = %+v, %v, want the last step 101", found, err)
+	}
+}
+
+func testNotFound(ctx context.Context, t *testing.T, s auth.Storage) {
+
+	if _, err := s.FindByID(ctx, -1); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("FindByID err = %v, want domainErrors.NotFound", err)
+	}
+	if _, err := s.FindByEmail(ctx, NewUser().Email); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("FindByEmail err = %v, want domainErrors.NotFound", err)
+	}
+	if _, err := s.UpdateLocale(ctx, -1, "ru"); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("UpdateLocale err = %v, want domainErrors.NotFound", err)
+	}
+	if _, err := s.VerifyEmail(ctx, -1); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("VerifyEmail err = %v, want domainErrors.NotFound", err)
+	}
+	if err := s.UpdatePassword(ctx, -1, "hashed-password"); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("UpdatePassword err = %v, want domainErrors.NotFound", err)
+	}
+	if err := s.SetTOTPSecret(ctx, -1, "JBSWY3DPEHPK3PXP"); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("SetTOTPSecret err = %v, want domainErrors.NotFound", err)
+	}
+	if err := s.EnableTOTP(ctx, -1, nil); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("EnableTOTP err = %v, want domainErrors.NotFound", err)
+	}
+	if err := s.UseTOTPStep(ctx, -1, 1); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("UseTOTPStep err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+// kind is the token kind the token tests save under.
+const kind = "storagetest"
+
+// RunTokens runs the suite against the token storage returned by newStorage, which is
+// called once per test.
+func RunTokens(t *testing.T, newStorage func(t *testing.T) auth.TokenStorage) {
+	conformance.Run(t, newStorage, []conformance.Test[auth.TokenStorage]{
+		{Name: "SaveAndTake", Fn: testTokenSaveAndTake},
+		{Name: "SaveRevokesPrevious", Fn: testTokenSaveRevokesPrevious},
+		{Name: "KindsAreSeparate", Fn: testTokenKindsAreSeparate},
+		{Name: "AllowToken", Fn: testTokenAllowToken},
+		{Name: "Expiry", Fn: testTokenExpiry},
+	}...)
+}
+
+func testTokenSaveAndTake(ctx context.Context, t *testing.T, s auth.TokenStorage) {
+	hash := uuid.New().String()
+
+	if err := s.SaveToken(ctx, kind, hash, 42, 60); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	userID, err := s.TakeToken(ctx, kind, hash)
+	if err != nil {
+		t.Fatalf("TakeToken: %v", err)
+	}
+	if userID != 42 {
+		t.Fatalf("TakeToken = %d, want 42", userID)
+	}
+
+	if _, err = s.TakeToken(ctx, kind, hash); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("second TakeToken err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testTokenSaveRevokesPrevious(ctx context.Context, t *testing.T, s auth.TokenStorage) {
+	first, second := uuid.New().String(), uuid.New().String()
+
+	if err := s.SaveToken(ctx, kind, first, 43, 60); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if err := s.SaveToken(ctx, kind, second, 43, 60); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	if _, err := s.TakeToken(ctx, kind, first); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("TakeToken of a revoked token err = %v, want domainErrors.NotFound", err)
+	}
+	if _, err := s.TakeToken(ctx, kind, second); err != nil {
+		t.Fatalf("TakeToken: %v", err)
+	}
+}
+
+func testTokenKindsAreSeparate(ctx context.Context, t *testing.T, s auth.TokenStorage) {
+	hash := uuid.New().String()
+
+	if err := s.SaveToken(ctx, kind, hash, 44, 60); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if _, err := s.TakeToken(ctx, kind+"-other", hash); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("TakeToken of another kind err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testTokenAllowToken(ctx context.Context, t *testing.T, s auth.TokenStorage) {
+	userID := int(uuid.New().ID())
+
+	for i, want := range []bool{true, false} {
+		ok, err := s.AllowToken(ctx, kind, userID, 60)
+		if err != nil {
+			t.Fatalf("AllowToken: %v", err)
+		}
+		if ok != want {
+			t.Fatalf("AllowToken call %d = %v, want %v", i+1, ok, want)
+		}
+	}
+
+	if ok, err := s.AllowToken(ctx, kind+"-other", userID, 60); err != nil || !ok {
+		t.Fatalf("AllowToken of another kind = %v, %v, want true", ok, err)
+	}
+	if ok, err := s.AllowToken(ctx, kind, userID, 0); err != nil || !ok {
+		t.Fatalf("AllowToken without an interval = %v, %v, want true", ok, err)
+	}
+}
+
+func testTokenExpiry(ctx context.Context, t *testing.T, s auth.TokenStorage) {
+	t.Parallel()
+	hash := uuid.New().String()
+
+	if err := s.SaveToken(ctx, kind, hash, 45, 1); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, err := s.TakeToken(ctx, kind, hash); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("TakeToken after expiry err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+// RunAttempts runs the suite against the attempt storage returned by newStorage, which
+// is called once per test.
+func RunAttempts(t *testing.T, newStorage func(t *testing.T) auth.AttemptStorage) {
+	conformance.Run(t, newStorage, []conformance.Test[auth.AttemptStorage]{
+		{Name: "FailAndReset", Fn: testAttemptFailAndReset},
+		{Name: "Block", Fn: testAttemptBlock},
+		{Name: "Expiry", Fn: testAttemptExpiry},
+	}...)
+}
+
+func testAttemptFailAndReset(ctx context.Context, t *testing.T, s auth.AttemptStorage) {
+	key := uuid.New().String()
+
+	for want := 1; want <= 3; want++ {
+		count, err := s.Fail(ctx, key, 60)
+		if err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+		if count != want {
+			t.Fatalf("Fail = %d, want %d", count, want)
+		}
+	}
+
+	if err := s.Forgive(ctx, key); err != nil {
+		t.Fatalf("Forgive: %v", err)
+	}
+	if count, err := s.Fail(ctx, key, 60); err != nil || count != 3 {
+		t.Fatalf("Fail after Forgive = %d, %v, want 3", count, err)
+	}
+
+	if err := s.Reset(ctx, key); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if count, err := s.Fail(ctx, key, 60); err != nil || count != 1 {
+		t.Fatalf("Fail after Reset = %d, %v, want 1", count, err)
+	}
+
+	// forgiving more than was counted does not go below zero
+	other := uuid.New().String()
+	for i := 0; i < 2; i++ {
+		if err := s.Forgive(ctx, other); err != nil {
+			t.Fatalf("Forgive: %v", err)
+		}
+	}
+	if count, err := s.Fail(ctx, other, 60); err != nil || count != 1 {
+		t.Fatalf("Fail after forgiving a missing key = %d, %v, want 1", count, err)
+	}
+}
+
+func testAttemptBlock(ctx context.Context, t *testing.T, s auth.AttemptStorage) {
+	key := uuid.New().String()
+
+	if left, err := s.Blocked(ctx, key); err != nil || left != 0 {
+		t.Fatalf("Blocked of a new key = %v, %v, want 0", left, err)
+	}
+
+	if err := s.Block(ctx, key, time.Minute); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	left, err := s.Blocked(ctx, key)
+	if err != nil {
+		t.Fatalf("Blocked: %v", err)
+	}
+	if left <= 0 || left > time.Minute {
+		t.Fatalf("Blocked = %v, want at most a minute", left)
+	}
+
+	if err = s.Reset(ctx, key); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if left, err = s.Blocked(ctx, key); err != nil || left != 0 {
+		t.Fatalf("Blocked after Reset = %v, %v, want 0", left, err)
+	}
+}
+
+func testAttemptExpiry(ctx context.Context, t *testing.T, s auth.AttemptStorage) {
+	t.Parallel()
+	key := uuid.New().String()
+
+	if _, err := s.Fail(ctx, key, 1); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	if err := s.Block(ctx, key, time.Second); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if count, err := s.Fail(ctx, key, 1); err != nil || count != 1 {
+		t.Fatalf("Fail after the window = %d, %v, want 1", count, err)
+	}
+	if left, err := s.Blocked(ctx, key); err != nil || left != 0 {
+		t.Fatalf("Blocked after expiry = %v, %v, want 0", left, err)
+	}
+}
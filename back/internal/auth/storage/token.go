@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	tokenPrefix = "auth-token"
+)
+
+type tokenStorage struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewTokenStorage(redisClient *redis.Client) auth.TokenStorage {
+	return &tokenStorage{redisClient: redisClient, basePrefix: tokenPrefix}
+}
+
+func (s *tokenStorage) SaveToken(ctx context.Context, kind string, hash string, userID int, expire int) error {
+	userKey := s.createUserKey(kind, userID)
+
+	previous, err := s.redisClient.GetSet(ctx, userKey, hash).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return errors.Wrap(err, "tokenStorage.SaveToken.redisClient.GetSet")
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, s.createKey(kind, previous))
+	}
+	pipe.Expire(ctx, userKey, time.Second*time.Duration(expire))
+	pipe.Set(ctx, s.createKey(kind, hash), userID, time.Second*time.Duration(expire))
+	if _, err = pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "tokenStorage.SaveToken.pipe.Exec")
+	}
+
+	return nil
+}
+
+// TakeToken deletes the token as it reads it, so it can only be taken once. Unknown,
+// revoked and expired tokens are reported with domainErrors.NotFound.
+func (s *tokenStorage) TakeToken(ctx context.Context, kind string, hash string) (int, error) {
+	userID, err := s.redisClient.GetDel(ctx, s.createKey(kind, hash)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, errors.Wrap(domainErrors.NotFound, "tokenStorage.TakeToken.redisClient.GetDel")
+		}
+		return 0, errors.Wrap(err, "tokenStorage.TakeToken.redisClient.GetDel")
+	}
+
+	if err = s.redisClient.Del(ctx, s.createUserKey(kind, userID)).Err(); err != nil {
+		return 0, errors.Wrap(err, "tokenStorage.TakeToken.redisClient.Del")
+	}
+
+	return userID, nil
+}
+
+func (s *tokenStorage) AllowToken(ctx context.Context, kind string, userID int, interval int) (bool, error) {
+	if interval <= 0 {
+		return true, nil
+	}
+
+	ok, err := s.redisClient.SetNX(ctx, s.createThrottleKey(kind, userID), 1, time.Second*time.Duration(interval)).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "tokenStorage.AllowToken.redisClient.SetNX")
+	}
+	return ok, nil
+}
+
+func (s *tokenStorage) createKey(kind string, hash string) string {
+	return fmt.Sprintf("%s: %s: %s", s.basePrefix, kind, hash)
+}
+
+func (s *tokenStorage) createUserKey(kind string, userID int) string {
+	return fmt.Sprintf("%s: %s-user: %d", s.basePrefix, kind, userID)
+}
+
+func (s *tokenStorage) createThrottleKey(kind string, userID int) string {
+	return fmt.Sprintf("%s: %s-throttle: %d", s.basePrefix, kind, userID)
+}
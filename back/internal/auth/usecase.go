@@ -7,6 +7,14 @@ import (
 
 type UseCase interface {
 	Register(ctx context.Context, user *models.User) (*models.User, error)
-	Login(ctx context.Context, user *models.User) (*models.User, error)
+	Login(ctx context.Context, user *models.User, ip string) (*models.User, string, error)
+	LoginTOTP(ctx context.Context, mfaToken string, code string, ip string) (*models.User, error)
 	GetByID(ctx context.Context, userID int) (*models.User, error)
+	UpdateLocale(ctx context.Context, userID int, locale string) (*models.User, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) (int, error)
+	ResendVerification(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	EnrollTOTP(ctx context.Context, userID int) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error)
 }
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// RequestPasswordReset mails a reset link to the user with the given email, at most
+// once per PasswordReset.ResendInterval. It succeeds for unknown emails and throttled
+// requests too, so the response does not tell which accounts exist.
+func (u *authUseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := u.authStorage.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return nil
+		}
+		return err
+	}
+
+	ok, err := u.tokenStorage.AllowToken(ctx, models.TokenPasswordReset, user.ID, u.cfg.PasswordReset.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		u.log.Infof("(RequestPasswordReset) a reset link was sent to user %d less than %d seconds ago", user.ID, u.cfg.PasswordReset.ResendInterval)
+		return nil
+	}
+
+	return u.issueToken(ctx, user, models.TokenPasswordReset, u.cfg.PasswordReset.URL, u.cfg.PasswordReset.Expire)
+}
+
+// ResetPassword sets a new password for the owner of the token and returns the ID of
+// the user. The token can not be used again, even when the update fails.
+func (u *authUseCase) ResetPassword(ctx context.Context, token string, password string) (int, error) {
+	user := &models.User{Password: strings.TrimSpace(password)}
+	if err := user.HashPassword(); err != nil {
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return 0, domainErrors.NewValidationError(domainErrors.FieldError{Field: "password", Rule: "max_bytes", Param: "72"})
+		}
+		return 0, errors.Wrap(err, "authUseCase.ResetPassword.HashPassword")
+	}
+
+	userID, err := u.tokenStorage.TakeToken(ctx, models.TokenPasswordReset, hashToken(token))
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return 0, domainErrors.NewValidationError(domainErrors.FieldError{Field: "token", Rule: "token"})
+		}
+		return 0, err
+	}
+
+	if err = u.authStorage.UpdatePassword(ctx, userID, user.Password); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
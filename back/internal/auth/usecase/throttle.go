@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"strings"
+	"time"
+)
+
+// checkLogin fails with a TooManyRequestsError while logins with the email or from the
+// IP address are blocked.
+func (u *authUseCase) checkLogin(ctx context.Context, email string, ip string) error {
+	for _, key := range []string{emailKey(email), ipKey(ip)} {
+		left, err := u.attemptStorage.Blocked(ctx, key)
+		if err != nil {
+			return err
+		}
+		if left > 0 {
+			u.log.Warnf("(security) blocked login, email: %s, ip: %s, key: %s, retry after: %s", email, ip, key, left)
+			return domainErrors.NewTooManyRequestsError(left)
+		}
+	}
+	return nil
+}
+
+// loginAttempt is a login counted by reserveLogin, with the failures of the email and
+// of the IP address including this one.
+type loginAttempt struct {
+	email      string
+	ip         string
+	failures   int
+	ipFailures int
+}
+
+// reserveLogin counts the attempt as a failure before the credentials are checked, so
+// concurrent attempts can not get past the limits. It fails with a TooManyRequestsError
+// while logins are blocked and when the attempt goes over a limit.
+func (u *authUseCase) reserveLogin(ctx context.Context, email string, ip string) (*loginAttempt, error) {
+	if err := u.checkLogin(ctx, email, ip); err != nil {
+		return nil, err
+	}
+
+	cfg := u.cfg.LoginThrottle
+	lockout := time.Second * time.Duration(cfg.Lockout)
+
+	failures, err := u.attemptStorage.Fail(ctx, emailKey(email), cfg.Window)
+	if err != nil {
+		return nil, err
+	}
+	ipFailures, err := u.attemptStorage.Fail(ctx, ipKey(ip), cfg.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, over := range []struct {
+		key      string
+		failures int
+		max      int
+	}{
+		{emailKey(email), failures, cfg.MaxFailures},
+		{ipKey(ip), ipFailures, cfg.IPMaxFailures},
+	} {
+		if over.failures <= over.max {
+			continue
+		}
+		u.log.Warnf("(security) blocked login, email: %s, ip: %s, key: %s, failures: %d", email, ip, over.key, over.failures)
+		if err = u.attemptStorage.Block(ctx, over.key, lockout); err != nil {
+			return nil, err
+		}
+		return nil, domainErrors.NewTooManyRequestsError(lockout)
+	}
+
+	return &loginAttempt{email: email, ip: ip, failures: failures, ipFailures: ipFailures}, nil
+}
+
+// loginFailed blocks logins with the email for a delay that doubles with every
+// failure, and locks both the email and the IP address once they reach their limit.
+func (u *authUseCase) loginFailed(ctx context.Context, attempt *loginAttempt) error {
+	cfg := u.cfg.LoginThrottle
+
+	u.log.Warnf("(security) failed login, email: %s, ip: %s, failures: %d", attempt.email, attempt.ip, attempt.failures)
+
+	var err error
+	if attempt.failures >= cfg.MaxFailures {
+		u.log.Warnf("(security) login locked, email: %s, ip: %s, for: %ds", attempt.email, attempt.ip, cfg.Lockout)
+		err = u.attemptStorage.Block(ctx, emailKey(attempt.email), time.Second*time.Duration(cfg.Lockout))
+	} else if delay := backoff(cfg.BaseDelay, cfg.MaxDelay, attempt.failures); delay > 0 {
+		err = u.attemptStorage.Block(ctx, emailKey(attempt.email), delay)
+	}
+	if err != nil {
+		return err
+	}
+
+	if attempt.ipFailures >= cfg.IPMaxFailures {
+		u.log.Warnf("(security) login locked, ip: %s, failures: %d, for: %ds", attempt.ip, attempt.ipFailures, cfg.Lockout)
+		return u.attemptStorage.Block(ctx, ipKey(attempt.ip), time.Second*time.Duration(cfg.Lockout))
+	}
+
+	return nil
+}
+
+// loginPassed takes back the attempt without forgetting earlier failures, for a step
+// of the login that succeeded when another one is still to come.
+func (u *authUseCase) loginPassed(ctx context.Context, attempt *loginAttempt) {
+	for _, key := range []string{emailKey(attempt.email), ipKey(attempt.ip)} {
+		if err := u.attemptStorage.Forgive(ctx, key); err != nil {
+			u.log.Warnf("(authUseCase.loginPassed) err: {%v}", err)
+		}
+	}
+}
+
+// loginSucceeded forgets the failures of the email. Failures from the IP address are
+// kept, so a known account can not be used to guess the passwords of others.
+func (u *authUseCase) loginSucceeded(ctx context.Context, attempt *loginAttempt) {
+	if err := u.attemptStorage.Reset(ctx, emailKey(attempt.email)); err != nil {
+		u.log.Warnf("(authUseCase.loginSucceeded) err: {%v}", err)
+	}
+	if err := u.attemptStorage.Forgive(ctx, ipKey(attempt.ip)); err != nil {
+		u.log.Warnf("(authUseCase.loginSucceeded) err: {%v}", err)
+	}
+}
+
+// backoff returns base seconds doubled for every failure after the first, at most max
+// seconds.
+func backoff(base int, max int, failures int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	return time.Second * time.Duration(delay)
+}
+
+func emailKey(email string) string {
+	return "email: " + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip: " + ip
+}
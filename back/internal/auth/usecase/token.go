@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/aakosarev/kanban-board/back/pkg/mailer"
+	"github.com/pkg/errors"
+	"net/url"
+	"time"
+)
+
+const mailTimeout = 30 * time.Second
+
+// issueToken saves a new token of the kind for the user and mails a link with it,
+// using the mail.<kind>.subject and mail.<kind>.body templates of the user's locale.
+// The mail is sent in the background, so callers take the same time whether a mail
+// is sent or not.
+func (u *authUseCase) issueToken(ctx context.Context, user *models.User, kind string, linkURL string, expire int) error {
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(linkURL)
+	if err != nil {
+		return errors.Wrap(err, "authUseCase.issueToken.url.Parse")
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	if err = u.tokenStorage.SaveToken(ctx, kind, hash, user.ID, expire); err != nil {
+		return err
+	}
+
+	minutes := (expire + 59) / 60
+	go u.send(&mailer.Message{
+		To:      user.Email,
+		Subject: i18n.T(user.Locale, "mail."+kind+".subject"),
+		Body:    i18n.T(user.Locale, "mail."+kind+".body", link.String(), minutes),
+	})
+
+	return nil
+}
+
+func (u *authUseCase) send(msg *mailer.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	if err := u.mailer.Send(ctx, msg); err != nil {
+		u.log.Errorf("(authUseCase.send) to: %s, err: {%v}", msg.To, err)
+	}
+}
+
+// newToken returns a random token for a link and the hash it is stored by, so a
+// leaked storage does not leak usable tokens.
+func newToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", errors.Wrap(err, "newToken.rand.Read")
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
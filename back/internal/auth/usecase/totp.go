@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/totp"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const recoveryCodesCount = 10
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// EnrollTOTP stores a new secret for the user. TOTP is only enabled once a code of the
+// secret is confirmed with ConfirmTOTP.
+func (u *authUseCase) EnrollTOTP(ctx context.Context, userID int) (*models.TOTPEnrollment, error) {
+	user, err := u.authStorage.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.authStorage.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{Secret: secret, URI: totp.URI(u.cfg.TOTP.Issuer, user.Email, secret)}, nil
+}
+
+// ConfirmTOTP enables TOTP when the code matches the enrolled secret and returns the
+// recovery codes. They are only stored hashed, so this is the only time they are shown.
+func (u *authUseCase) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := u.authStorage.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.Wrap(domainErrors.Conflict, "TOTP is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.Wrap(domainErrors.Unprocessable, "TOTP enrollment has not been started")
+	}
+
+	step, ok, err := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domainErrors.NewValidationError(domainErrors.FieldError{Field: "code", Rule: "totp"})
+	}
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	err = u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.authStorage.UseTOTPStep(ctx, userID, step); err != nil {
+			if errors.Is(err, domainErrors.Conflict) {
+				return domainErrors.NewValidationError(domainErrors.FieldError{Field: "code", Rule: "totp"})
+			}
+			return err
+		}
+		return u.authStorage.EnableTOTP(ctx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// LoginTOTP completes the login of a user with TOTP enabled. The MFA token comes
+// from Login and can only be used once, so every wrong code costs a password login.
+// A TOTP code is not accepted again once it has been used, and neither are the codes
+// of earlier steps. A recovery code is accepted instead of a TOTP code and can only
+// be used once. Wrong codes are throttled like wrong passwords.
+func (u *authUseCase) LoginTOTP(ctx context.Context, mfaToken string, code string, ip string) (*models.User, error) {
+	userID, err := u.tokenStorage.TakeToken(ctx, models.TokenMFA, hashToken(mfaToken))
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return nil, errors.Wrap(domainErrors.Unauthorized, "login has expired")
+		}
+		return nil, err
+	}
+
+	user, err := u.authStorage.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err := u.reserveLogin(ctx, user.Email, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok, err := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if err = u.authStorage.UseTOTPStep(ctx, userID, step); err != nil {
+			if errors.Is(err, domainErrors.Conflict) {
+				return nil, u.wrongCredentials(ctx, attempt)
+			}
+			return nil, err
+		}
+	} else {
+		if err = u.authStorage.UseRecoveryCode(ctx, userID, hashRecoveryCode(code)); err != nil {
+			if errors.Is(err, domainErrors.NotFound) {
+				return nil, u.wrongCredentials(ctx, attempt)
+			}
+			return nil, err
+		}
+	}
+
+	u.loginSucceeded(ctx, attempt)
+
+	user.SanitizePassword()
+
+	return user, nil
+}
+
+// newMFAToken starts the second step of the login of a user with TOTP enabled.
+func (u *authUseCase) newMFAToken(ctx context.Context, userID int) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err = u.tokenStorage.SaveToken(ctx, models.TokenMFA, hash, userID, u.cfg.TOTP.LoginExpire); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// newRecoveryCode returns 50 random bits as ten base32 characters, formatted as
+// xxxxx-xxxxx.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "newRecoveryCode.rand.Read")
+	}
+
+	code := recoveryCodeEncoding.EncodeToString(b)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces, which users may type differently.
+func hashRecoveryCode(code string) string {
+	return hashToken(strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code)))
+}
@@ -5,36 +5,71 @@ import (
 	"github.com/aakosarev/kanban-board/back/internal/auth"
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/models"
-	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/aakosarev/kanban-board/back/pkg/mailer"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type authUseCase struct {
-	cfg         *config.Config
-	authStorage auth.Storage
-	log         logger.Logger
+	cfg            *config.Config
+	txManager      transaction.Manager
+	authStorage    auth.Storage
+	tokenStorage   auth.TokenStorage
+	attemptStorage auth.AttemptStorage
+	mailer         mailer.Mailer
+	log            logger.Logger
 }
 
-func NewAuthUseCase(cfg *config.Config, authStorage auth.Storage, log logger.Logger) auth.UseCase {
-	return &authUseCase{cfg: cfg, authStorage: authStorage, log: log}
+func NewAuthUseCase(
+	cfg *config.Config,
+	txManager transaction.Manager,
+	authStorage auth.Storage,
+	tokenStorage auth.TokenStorage,
+	attemptStorage auth.AttemptStorage,
+	mailer mailer.Mailer,
+	log logger.Logger,
+) auth.UseCase {
+	return &authUseCase{cfg: cfg, txManager: txManager, authStorage: authStorage, tokenStorage: tokenStorage, attemptStorage: attemptStorage, mailer: mailer, log: log}
 }
 
 func (u *authUseCase) Register(ctx context.Context, user *models.User) (*models.User, error) {
-	existsUser, err := u.authStorage.FindByEmail(ctx, user.Email)
-	if existsUser != nil || err == nil {
-		return nil, errors.New(httpErrors.ErrEmailAlreadyExists)
+	if user.Locale == "" {
+		user.Locale = i18n.FromContext(ctx)
 	}
 
-	if err = user.PrepareCreate(); err != nil {
+	if err := user.PrepareCreate(); err != nil {
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return nil, domainErrors.NewValidationError(domainErrors.FieldError{Field: "password", Rule: "max_bytes", Param: "72"})
+		}
 		return nil, errors.Wrap(err, "authUseCase.Register.PrepareCreate")
 	}
 
-	createdUser, err := u.authStorage.Register(ctx, user)
+	var createdUser *models.User
+
+	err := u.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		existsUser, err := u.authStorage.FindByEmail(ctx, user.Email)
+		if existsUser != nil || err == nil {
+			return errors.Wrap(domainErrors.Conflict, "user with given email already exists")
+		}
+		if !errors.Is(err, domainErrors.NotFound) {
+			return err
+		}
+
+		createdUser, err = u.authStorage.Register(ctx, user)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if err = u.sendVerification(ctx, createdUser); err != nil {
+		u.log.Errorf("(authUseCase.Register.sendVerification) err: {%v}", err)
+	}
+
 	createdUser.SanitizePassword()
 
 	return createdUser, nil
@@ -51,17 +86,62 @@ func (u *authUseCase) GetByID(ctx context.Context, userID int) (*models.User, er
 	return user, nil
 }
 
-func (u *authUseCase) Login(ctx context.Context, user *models.User) (*models.User, error) {
-	foundUser, err := u.authStorage.FindByEmail(ctx, user.Email)
+func (u *authUseCase) UpdateLocale(ctx context.Context, userID int, locale string) (*models.User, error) {
+	user, err := u.authStorage.UpdateLocale(ctx, userID, locale)
 	if err != nil {
 		return nil, err
 	}
 
+	user.SanitizePassword()
+
+	return user, nil
+}
+
+// Login reports an unknown email and a wrong password the same way, so the response
+// does not tell which accounts exist. For users with TOTP enabled no user is returned,
+// only an MFA token to pass to LoginTOTP with a code. Failed logins are throttled by
+// email and by the IP address of the client.
+func (u *authUseCase) Login(ctx context.Context, user *models.User, ip string) (*models.User, string, error) {
+	attempt, err := u.reserveLogin(ctx, user.Email, ip)
+	if err != nil {
+		return nil, "", err
+	}
+
+	foundUser, err := u.authStorage.FindByEmail(ctx, user.Email)
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return nil, "", u.wrongCredentials(ctx, attempt)
+		}
+		return nil, "", err
+	}
+
 	if err = foundUser.ComparePasswords(user.Password); err != nil {
-		return nil, errors.Wrap(err, "authUseCase.Login.ComparePasswords")
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, "", u.wrongCredentials(ctx, attempt)
+		}
+		return nil, "", errors.Wrap(err, "authUseCase.Login.ComparePasswords")
 	}
 
+	// the failures are only forgotten once the second step succeeds too
+	if foundUser.TOTPEnabled {
+		mfaToken, err := u.newMFAToken(ctx, foundUser.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		u.loginPassed(ctx, attempt)
+		return nil, mfaToken, nil
+	}
+
+	u.loginSucceeded(ctx, attempt)
+
 	foundUser.SanitizePassword()
 
-	return foundUser, nil
+	return foundUser, "", nil
+}
+
+func (u *authUseCase) wrongCredentials(ctx context.Context, attempt *loginAttempt) error {
+	if err := u.loginFailed(ctx, attempt); err != nil {
+		return err
+	}
+	return errors.Wrap(domainErrors.Unauthorized, "wrong credentials")
 }
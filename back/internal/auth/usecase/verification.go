@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+)
+
+// ResendVerification mails a new verification link, at most once per
+// Verification.ResendInterval. Links sent before stop working.
+func (u *authUseCase) ResendVerification(ctx context.Context, userID int) error {
+	user, err := u.authStorage.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return errors.Wrap(domainErrors.Conflict, "email is already verified")
+	}
+
+	return u.sendVerification(ctx, user)
+}
+
+func (u *authUseCase) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	userID, err := u.tokenStorage.TakeToken(ctx, models.TokenVerification, hashToken(token))
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return nil, domainErrors.NewValidationError(domainErrors.FieldError{Field: "token", Rule: "token"})
+		}
+		return nil, err
+	}
+
+	user, err := u.authStorage.VerifyEmail(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.SanitizePassword()
+
+	return user, nil
+}
+
+func (u *authUseCase) sendVerification(ctx context.Context, user *models.User) error {
+	ok, err := u.tokenStorage.AllowToken(ctx, models.TokenVerification, user.ID, u.cfg.Verification.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Wrapf(domainErrors.TooManyRequests, "a verification link was sent less than %d seconds ago", u.cfg.Verification.ResendInterval)
+	}
+
+	return u.issueToken(ctx, user, models.TokenVerification, u.cfg.Verification.URL, u.cfg.Verification.Expire)
+}
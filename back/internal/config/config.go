@@ -18,24 +18,38 @@ func init() {
 }
 
 type Config struct {
-	ServiceName string         `mapstructure:"serviceName"`
-	Http        Http           `mapstructure:"http"`
-	Cookie      Cookie         `mapstructure:"cookie"`
-	Session     Session        `mapstructure:"session"`
-	Postgres    Postgres       `mapstructure:"postgres"`
-	Redis       Redis          `mapstructure:"redis"`
-	Logger      *logger.Config `mapstructure:"logger"`
+	ServiceName   string         `mapstructure:"serviceName"`
+	Http          Http           `mapstructure:"http"`
+	Cookie        Cookie         `mapstructure:"cookie"`
+	Session       Session        `mapstructure:"session"`
+	History       History        `mapstructure:"history"`
+	Idempotency   Idempotency    `mapstructure:"idempotency"`
+	PasswordReset PasswordReset  `mapstructure:"passwordReset"`
+	Verification  Verification   `mapstructure:"verification"`
+	TOTP          TOTP           `mapstructure:"totp"`
+	LoginThrottle LoginThrottle  `mapstructure:"loginThrottle"`
+	RateLimit     RateLimit      `mapstructure:"rateLimit"`
+	Mail          Mail           `mapstructure:"mail"`
+	Storage       Storage        `mapstructure:"storage"`
+	Postgres      Postgres       `mapstructure:"postgres"`
+	Redis         Redis          `mapstructure:"redis"`
+	Logger        *logger.Config `mapstructure:"logger"`
 }
 
+// Http.AllowOrigins lists the frontends allowed to make requests with the session
+// cookie. Browsers only send credentials cross-origin to an explicitly listed origin.
 type Http struct {
-	Port                string `mapstructure:"port" validate:"required"`
-	Development         bool   `mapstructure:"development"`
-	BasePath            string `mapstructure:"basePath" validate:"required"`
-	AuthPath            string `mapstructure:"authPath" validate:"required"`
-	ColumnPath          string `mapstructure:"columnPath" validate:"required"`
-	TaskPath            string `mapstructure:"taskPath" validate:"required"`
-	BoardPath           string `mapstructure:"boardPath" validate:"required"`
-	DebugErrorsResponse bool   `mapstructure:"debugErrorsResponse"`
+	Port                string   `mapstructure:"port" validate:"required"`
+	Development         bool     `mapstructure:"development"`
+	BasePath            string   `mapstructure:"basePath" validate:"required"`
+	AuthPath            string   `mapstructure:"authPath" validate:"required"`
+	ColumnPath          string   `mapstructure:"columnPath" validate:"required"`
+	TaskPath            string   `mapstructure:"taskPath" validate:"required"`
+	BoardPath           string   `mapstructure:"boardPath" validate:"required"`
+	TemplatePath        string   `mapstructure:"templatePath" validate:"required"`
+	DebugErrorsResponse bool     `mapstructure:"debugErrorsResponse"`
+	TrustProxy          bool     `mapstructure:"trustProxy"`
+	AllowOrigins        []string `mapstructure:"allowOrigins" validate:"required,min=1,dive,url"`
 }
 
 type Session struct {
@@ -44,6 +58,87 @@ type Session struct {
 	Expire int    `mapstructure:"expire"`
 }
 
+type History struct {
+	Limit  int `mapstructure:"limit" validate:"required,gt=0"`
+	Expire int `mapstructure:"expire" validate:"required,gt=0"`
+}
+
+// Idempotency.Expire is how long, in seconds, a response is replayed for retries
+// with the same Idempotency-Key.
+type Idempotency struct {
+	Expire int `mapstructure:"expire" validate:"required,gt=0"`
+}
+
+// PasswordReset.Expire is how long, in seconds, a reset link is valid, and
+// ResendInterval how long a user waits before another link can be sent. The token is
+// added to URL as the token query parameter.
+type PasswordReset struct {
+	Expire         int    `mapstructure:"expire" validate:"required,gt=0"`
+	ResendInterval int    `mapstructure:"resendInterval" validate:"gte=0"`
+	URL            string `mapstructure:"url" validate:"required,url"`
+}
+
+// Verification.Expire is how long, in seconds, an email verification link is valid,
+// and ResendInterval how long a user waits before another link can be sent. With
+// Required set, only users with a verified email can create boards.
+type Verification struct {
+	Expire         int    `mapstructure:"expire" validate:"required,gt=0"`
+	ResendInterval int    `mapstructure:"resendInterval" validate:"gte=0"`
+	URL            string `mapstructure:"url" validate:"required,url"`
+	Required       bool   `mapstructure:"required"`
+}
+
+// TOTP.Issuer names the service in authenticator apps. TOTP.LoginExpire is how long,
+// in seconds, a user with TOTP enabled has to enter a code after the password.
+type TOTP struct {
+	Issuer      string `mapstructure:"issuer" validate:"required"`
+	LoginExpire int    `mapstructure:"loginExpire" validate:"required,gt=0"`
+}
+
+// LoginThrottle limits failed logins. After a failure for an email, logins with it are
+// blocked for BaseDelay seconds, doubling with every further failure up to MaxDelay.
+// After MaxFailures failures for an email, or IPMaxFailures from an IP address, logins
+// are locked for Lockout seconds. Failures are forgotten Window seconds after the last.
+type LoginThrottle struct {
+	Window        int `mapstructure:"window" validate:"required,gt=0"`
+	BaseDelay     int `mapstructure:"baseDelay" validate:"gte=0"`
+	MaxDelay      int `mapstructure:"maxDelay" validate:"gtefield=BaseDelay"`
+	MaxFailures   int `mapstructure:"maxFailures" validate:"required,gt=0"`
+	IPMaxFailures int `mapstructure:"ipMaxFailures" validate:"required,gt=0"`
+	Lockout       int `mapstructure:"lockout" validate:"required,gt=0"`
+}
+
+// RateLimit holds the request limits of the route groups. The board policy also covers
+// the column and task routes.
+type RateLimit struct {
+	Auth     RateLimitPolicy `mapstructure:"auth"`
+	Board    RateLimitPolicy `mapstructure:"board"`
+	Template RateLimitPolicy `mapstructure:"template"`
+}
+
+// RateLimitPolicy allows bursts of Limit requests and gives back Limit requests every
+// Period seconds. A zero Limit turns rate limiting off.
+type RateLimitPolicy struct {
+	Limit  int `mapstructure:"limit" validate:"gte=0"`
+	Period int `mapstructure:"period" validate:"required_with=Limit,gte=0"`
+}
+
+// Mail.Driver is either smtp or outbox. The outbox driver writes messages to OutboxDir,
+// or only logs them when it is empty, and is meant for local use.
+type Mail struct {
+	Driver       string `mapstructure:"driver" validate:"required,oneof=smtp outbox"`
+	From         string `mapstructure:"from" validate:"required,email"`
+	SMTPHost     string `mapstructure:"smtpHost" validate:"required_if=Driver smtp"`
+	SMTPPort     int    `mapstructure:"smtpPort" validate:"required_if=Driver smtp"`
+	SMTPUsername string `mapstructure:"smtpUsername"`
+	SMTPPassword string `mapstructure:"smtpPassword"`
+	OutboxDir    string `mapstructure:"outboxDir"`
+}
+
+type Storage struct {
+	Driver string `mapstructure:"driver" validate:"required,oneof=postgres memory"`
+}
+
 type Cookie struct {
 	MaxAge   int  `mapstructure:"maxAge"`
 	Secure   bool `mapstructure:"secure"`
@@ -68,6 +163,8 @@ type Redis struct {
 	PoolTimeout    int    `mapstructure:"poolTimeout"`
 	Password       string `mapstructure:"password"`
 	DB             int    `mapstructure:"db"`
+	BoardCache     bool   `mapstructure:"boardCache"`
+	BoardCacheTTL  int    `mapstructure:"boardCacheTTL" validate:"required_with=BoardCache,gte=0"`
 }
 
 func InitConfig() (*Config, error) {
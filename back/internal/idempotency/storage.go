@@ -0,0 +1,15 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+)
+
+type Storage interface {
+	// Lock stores response under key unless the key is already taken and reports
+	// whether it did.
+	Lock(ctx context.Context, key string, response *models.IdempotentResponse, expire int) (bool, error)
+	Get(ctx context.Context, key string) (*models.IdempotentResponse, error)
+	Save(ctx context.Context, key string, response *models.IdempotentResponse, expire int) error
+	Delete(ctx context.Context, key string) error
+}
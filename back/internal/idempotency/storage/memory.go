@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/idempotency"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+type memoryResponse struct {
+	response models.IdempotentResponse
+	expireAt time.Time
+}
+
+type memoryIdempotencyStorage struct {
+	mu         sync.Mutex
+	responses  map[string]memoryResponse
+	basePrefix string
+}
+
+func NewMemoryIdempotencyStorage() idempotency.Storage {
+	return &memoryIdempotencyStorage{responses: make(map[string]memoryResponse), basePrefix: basePrefix}
+}
+
+func (s *memoryIdempotencyStorage) Lock(ctx context.Context, key string, response *models.IdempotentResponse, expire int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.get(s.createKey(key)); ok {
+		return false, nil
+	}
+
+	s.set(s.createKey(key), response, expire)
+	return true, nil
+}
+
+// Get reports missing and expired keys with domainErrors.NotFound.
+func (s *memoryIdempotencyStorage) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mr, ok := s.get(s.createKey(key))
+	if !ok {
+		return nil, errors.Wrap(domainErrors.NotFound, "memoryIdempotencyStorage.Get")
+	}
+
+	response := mr.response
+	return &response, nil
+}
+
+func (s *memoryIdempotencyStorage) Save(ctx context.Context, key string, response *models.IdempotentResponse, expire int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(s.createKey(key), response, expire)
+	return nil
+}
+
+func (s *memoryIdempotencyStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.responses, s.createKey(key))
+	return nil
+}
+
+func (s *memoryIdempotencyStorage) get(key string) (memoryResponse, bool) {
+	mr, ok := s.responses[key]
+	if ok && time.Now().After(mr.expireAt) {
+		delete(s.responses, key)
+		return memoryResponse{}, false
+	}
+	return mr, ok
+}
+
+func (s *memoryIdempotencyStorage) set(key string, response *models.IdempotentResponse, expire int) {
+	s.responses[key] = memoryResponse{response: *response, expireAt: time.Now().Add(time.Second * time.Duration(expire))}
+}
+
+func (s *memoryIdempotencyStorage) createKey(key string) string {
+	return fmt.Sprintf("%s: %s", s.basePrefix, key)
+}
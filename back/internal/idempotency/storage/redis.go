@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/idempotency"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	basePrefix = "idempotency"
+)
+
+type idempotencyStorage struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewIdempotencyStorage(redisClient *redis.Client) idempotency.Storage {
+	return &idempotencyStorage{redisClient: redisClient, basePrefix: basePrefix}
+}
+
+func (s *idempotencyStorage) Lock(ctx context.Context, key string, response *models.IdempotentResponse, expire int) (bool, error) {
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return false, errors.Wrap(err, "idempotencyStorage.Lock.json.Marshal")
+	}
+
+	ok, err := s.redisClient.SetNX(ctx, s.createKey(key), responseBytes, time.Second*time.Duration(expire)).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "idempotencyStorage.Lock.redisClient.SetNX")
+	}
+	return ok, nil
+}
+
+func (s *idempotencyStorage) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	responseBytes, err := s.redisClient.Get(ctx, s.createKey(key)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.Wrap(domainErrors.NotFound, "idempotencyStorage.Get.redisClient.Get")
+		}
+		return nil, errors.Wrap(err, "idempotencyStorage.Get.redisClient.Get")
+	}
+
+	response := &models.IdempotentResponse{}
+	if err = json.Unmarshal(responseBytes, response); err != nil {
+		return nil, errors.Wrap(err, "idempotencyStorage.Get.json.Unmarshal")
+	}
+	return response, nil
+}
+
+func (s *idempotencyStorage) Save(ctx context.Context, key string, response *models.IdempotentResponse, expire int) error {
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return errors.Wrap(err, "idempotencyStorage.Save.json.Marshal")
+	}
+
+	if err = s.redisClient.Set(ctx, s.createKey(key), responseBytes, time.Second*time.Duration(expire)).Err(); err != nil {
+		return errors.Wrap(err, "idempotencyStorage.Save.redisClient.Set")
+	}
+	return nil
+}
+
+func (s *idempotencyStorage) Delete(ctx context.Context, key string) error {
+	if err := s.redisClient.Del(ctx, s.createKey(key)).Err(); err != nil {
+		return errors.Wrap(err, "idempotencyStorage.Delete.redisClient.Del")
+	}
+	return nil
+}
+
+func (s *idempotencyStorage) createKey(key string) string {
+	return fmt.Sprintf("%s: %s", s.basePrefix, key)
+}
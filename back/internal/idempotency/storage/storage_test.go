@@ -0,0 +1,18 @@
+package storage_test
+
+import (
+	idempotencyS "github.com/aakosarev/kanban-board/back/internal/idempotency/storage"
+	"github.com/aakosarev/kanban-board/back/internal/idempotency/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/redistest"
+	"testing"
+)
+
+func TestMemoryIdempotencyStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Fresh(idempotencyS.NewMemoryIdempotencyStorage))
+}
+
+// TestRedisIdempotencyStorage runs only when REDIS_ADDR points to a Redis instance.
+func TestRedisIdempotencyStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Shared(idempotencyS.NewIdempotencyStorage(redistest.Client(t))))
+}
@@ -0,0 +1,123 @@
+// Package storagetest is a conformance suite for idempotency.Storage implementations.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/idempotency"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Run runs the suite against the storage returned by newStorage, which is called
+// once per test.
+func Run(t *testing.T, newStorage func(t *testing.T) idempotency.Storage) {
+	conformance.Run(t, newStorage, []conformance.Test[idempotency.Storage]{
+		{Name: "Lock", Fn: testLock},
+		{Name: "Save", Fn: testSave},
+		{Name: "Delete", Fn: testDelete},
+		{Name: "NotFound", Fn: testNotFound},
+		{Name: "Expiry", Fn: testExpiry},
+	}...)
+}
+
+func testLock(ctx context.Context, t *testing.T, s idempotency.Storage) {
+	key := uuid.New().String()
+
+	ok, err := s.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: "first"}, 60)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if !ok {
+		t.Fatal("Lock of a new key = false, want true")
+	}
+
+	if ok, err = s.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: "second"}, 60); err != nil || ok {
+		t.Fatalf("second Lock = %v, %v, want false", ok, err)
+	}
+
+	response, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if response.Fingerprint != "first" || response.Status != 0 {
+		t.Fatalf("Get = %+v, want the pending response of the first Lock", response)
+	}
+}
+
+func testSave(ctx context.Context, t *testing.T, s idempotency.Storage) {
+	key := uuid.New().String()
+
+	if _, err := s.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: "fingerprint"}, 60); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	want := &models.IdempotentResponse{
+		Fingerprint: "fingerprint",
+		Status:      http.StatusCreated,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":1}`),
+	}
+	if err := s.Save(ctx, key, want, 60); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Fingerprint != want.Fingerprint || got.Status != want.Status || got.ContentType != want.ContentType || !bytes.Equal(got.Body, want.Body) {
+		t.Fatalf("Get = %+v, want %+v", got, want)
+	}
+}
+
+func testDelete(ctx context.Context, t *testing.T, s idempotency.Storage) {
+	key := uuid.New().String()
+
+	if _, err := s.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: "fingerprint"}, 60); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := s.Get(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("Get after Delete err = %v, want domainErrors.NotFound", err)
+	}
+	if ok, err := s.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: "fingerprint"}, 60); err != nil || !ok {
+		t.Fatalf("Lock after Delete = %v, %v, want true", ok, err)
+	}
+}
+
+func testNotFound(ctx context.Context, t *testing.T, s idempotency.Storage) {
+	if _, err := s.Get(ctx, uuid.New().String()); !errors.Is(err, domainErrors.NotFound) {
+		t.Errorf("Get err = %v, want domainErrors.NotFound", err)
+	}
+	if err := s.Delete(ctx, uuid.New().String()); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func testExpiry(ctx context.Context, t *testing.T, s idempotency.Storage) {
+	t.Parallel()
+	key := uuid.New().String()
+
+	if err := s.Save(ctx, key, &models.IdempotentResponse{Fingerprint: "fingerprint", Status: http.StatusOK}, 1); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, err := s.Get(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("Get after expiry err = %v, want domainErrors.NotFound", err)
+	}
+	if ok, err := s.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: "fingerprint"}, 60); err != nil || !ok {
+		t.Fatalf("Lock after expiry = %v, %v, want true", ok, err)
+	}
+}
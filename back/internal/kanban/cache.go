@@ -0,0 +1,16 @@
+package kanban
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+)
+
+// BoardCache keeps serialized boards per version. Invalidate bumps the version, so a
+// board read before a write can never be served after it.
+type BoardCache interface {
+	Version(ctx context.Context, userID int) (int64, error)
+	// GetBoard returns nil without an error on a cache miss.
+	GetBoard(ctx context.Context, userID int, version int64) (*models.Board, error)
+	SetBoard(ctx context.Context, userID int, version int64, board *models.Board) error
+	Invalidate(ctx context.Context, userID int) error
+}
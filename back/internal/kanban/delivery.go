@@ -5,12 +5,23 @@ import "github.com/labstack/echo/v4"
 type Handlers interface {
 	CreateColumn() echo.HandlerFunc
 	DeleteColumn() echo.HandlerFunc
-	ChangeNameColumn() echo.HandlerFunc
+	PatchColumn() echo.HandlerFunc
 
 	CreateTask() echo.HandlerFunc
 	DeleteTask() echo.HandlerFunc
-	ChangeDescriptionTask() echo.HandlerFunc
-	ChangeColumnIDTask() echo.HandlerFunc
+	PatchTask() echo.HandlerFunc
+
+	CloneColumn() echo.HandlerFunc
+	CloneTask() echo.HandlerFunc
+
+	MoveTask() echo.HandlerFunc
+	CopyTask() echo.HandlerFunc
+	BulkTasks() echo.HandlerFunc
+	ExecuteBatch() echo.HandlerFunc
+
+	Undo() echo.HandlerFunc
+	Redo() echo.HandlerFunc
 
 	GetKanbanBoardByUserID() echo.HandlerFunc
+	GetTasksPageByColumnID() echo.HandlerFunc
 }
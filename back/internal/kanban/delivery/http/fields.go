@@ -0,0 +1,88 @@
+package http
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+var taskFields = map[string]func(t *models.T) interface{}{
+	"id":          func(t *models.T) interface{} { return t.ID },
+	"column_id":   func(t *models.T) interface{} { return t.ColumnID },
+	"description": func(t *models.T) interface{} { return t.Description },
+}
+
+type sparseBoard struct {
+	Columns []*sparseColumn `json:"columns"`
+}
+
+type sparseColumn struct {
+	ID         int                      `json:"id"`
+	Name       string                   `json:"name"`
+	Tasks      []map[string]interface{} `json:"tasks"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+type sparseTaskPage struct {
+	Tasks      []map[string]interface{} `json:"tasks"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+// parseFields parses a fields=id,description query parameter. It returns nil when the
+// parameter is missing, which means all fields.
+func parseFields(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(raw, ",")
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+		if _, ok := taskFields[fields[i]]; !ok {
+			return nil, errors.Wrapf(httpErrors.BadRequest, "unknown field %q", fields[i])
+		}
+	}
+
+	return fields, nil
+}
+
+// parseLimit parses a limit query parameter. A missing limit is returned as 0.
+func parseLimit(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return 0, errors.Wrapf(httpErrors.BadRequest, "invalid limit %q", raw)
+	}
+
+	return limit, nil
+}
+
+func selectTaskFields(tasks []*models.T, fields []string) []map[string]interface{} {
+	selected := make([]map[string]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		m := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			m[field] = taskFields[field](task)
+		}
+		selected = append(selected, m)
+	}
+	return selected
+}
+
+func selectBoardFields(board *models.Board, fields []string) *sparseBoard {
+	sb := &sparseBoard{Columns: make([]*sparseColumn, 0, len(board.Columns))}
+	for _, col := range board.Columns {
+		sb.Columns = append(sb.Columns, &sparseColumn{
+			ID:         col.ID,
+			Name:       col.Name,
+			Tasks:      selectTaskFields(col.Tasks, fields),
+			NextCursor: col.NextCursor,
+		})
+	}
+	return sb
+}
@@ -1,13 +1,15 @@
 package http
 
 import (
+	"context"
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/middleware"
 	"github.com/aakosarev/kanban-board/back/internal/models"
 	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
 	"github.com/aakosarev/kanban-board/back/pkg/utils"
-	"github.com/go-playground/validator"
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"strconv"
@@ -17,6 +19,7 @@ type KanbanHandlers struct {
 	taskGroup   *echo.Group
 	columnGroup *echo.Group
 	boardGroup  *echo.Group
+	mw          *middleware.Manager
 	log         logger.Logger
 	cfg         *config.Config
 	v           *validator.Validate
@@ -27,26 +30,36 @@ func NewKanbanHandlers(
 	taskGroup *echo.Group,
 	columnGroup *echo.Group,
 	boardGroup *echo.Group,
+	mw *middleware.Manager,
 	log logger.Logger,
 	cfg *config.Config,
 	v *validator.Validate,
 	kanbanUC kanban.UseCase,
 ) *KanbanHandlers {
-	return &KanbanHandlers{taskGroup: taskGroup, columnGroup: columnGroup, boardGroup: boardGroup, log: log, cfg: cfg, v: v, kanbanUC: kanbanUC}
+	return &KanbanHandlers{taskGroup: taskGroup, columnGroup: columnGroup, boardGroup: boardGroup, mw: mw, log: log, cfg: cfg, v: v, kanbanUC: kanbanUC}
 }
 
 func (h *KanbanHandlers) CreateColumn() echo.HandlerFunc {
+	type NewColumn struct {
+		Name string `json:"name"`
+	}
 	return func(c echo.Context) error {
-		column := &models.Column{}
-		if err := utils.ReadRequest(c, column); err != nil {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		req := &NewColumn{}
+		if err = utils.ReadRequest(c, req); err != nil {
 			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
-		createdColumn, err := h.kanbanUC.CreateColumn(c.Request().Context(), column)
+		createdColumn, err := h.kanbanUC.CreateColumn(c.Request().Context(), user.ID, &models.Column{Name: req.Name})
 		if err != nil {
 			h.log.Errorf("(kanbanUC.CreateColumn) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
 		return c.JSON(http.StatusCreated, createdColumn)
@@ -55,61 +68,77 @@ func (h *KanbanHandlers) CreateColumn() echo.HandlerFunc {
 
 func (h *KanbanHandlers) DeleteColumn() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
 		columnIDStr := c.Param("column_id")
 		columnID, err := strconv.Atoi(columnIDStr)
 		if err != nil {
 			h.log.Errorf("(KanbanHandlers.DeleteColumn.Atoi) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, httpErrors.BadRequest, h.cfg.Http.DebugErrorsResponse)
+			return httpErrors.BadRequest
 		}
 
-		if err = h.kanbanUC.DeleteColumn(c.Request().Context(), columnID); err != nil {
+		if err = h.kanbanUC.DeleteColumn(c.Request().Context(), user.ID, columnID); err != nil {
 			h.log.Errorf("(kanbanUC.DeleteColumn) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
 		return c.NoContent(http.StatusOK)
 	}
 }
 
-func (h *KanbanHandlers) ChangeNameColumn() echo.HandlerFunc {
+func (h *KanbanHandlers) PatchColumn() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
 		columnIDStr := c.Param("column_id")
 		columnID, err := strconv.Atoi(columnIDStr)
 		if err != nil {
-			h.log.Errorf("(KanbanHandlers.ChangeNameColumn.Atoi) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, httpErrors.BadRequest, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(KanbanHandlers.PatchColumn.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
 		}
 
-		column := &models.Column{}
-		if err := utils.ReadRequest(c, column); err != nil {
-			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+		apply, err := readPatch[models.Column](c, h.v)
+		if err != nil {
+			h.log.Errorf("(readPatch) err: {%v}", err)
+			return err
 		}
 
-		column.ID = columnID
-
-		updatedColumn, err := h.kanbanUC.ChangeNameColumn(c.Request().Context(), column)
+		column, err := h.kanbanUC.PatchColumn(c.Request().Context(), user.ID, columnID, apply)
 		if err != nil {
-			h.log.Errorf("(kanbanUC.ChangeNameColumn) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(kanbanUC.PatchColumn) err: {%v}", err)
+			return err
 		}
 
-		return c.JSON(http.StatusOK, updatedColumn)
+		return c.JSON(http.StatusOK, column)
 	}
 }
 
 func (h *KanbanHandlers) CreateTask() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
 		task := &models.Task{}
-		if err := utils.ReadRequest(c, task); err != nil {
+		if err = utils.ReadRequest(c, task); err != nil {
 			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
-		createdTask, err := h.kanbanUC.CreateTask(c.Request().Context(), task)
+		createdTask, err := h.kanbanUC.CreateTask(c.Request().Context(), user.ID, task)
 		if err != nil {
 			h.log.Errorf("(kanbanUC.CreateTask) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
 		return c.JSON(http.StatusCreated, createdTask)
@@ -118,91 +147,304 @@ func (h *KanbanHandlers) CreateTask() echo.HandlerFunc {
 
 func (h *KanbanHandlers) DeleteTask() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
 		taskIDStr := c.Param("task_id")
 		taskID, err := strconv.Atoi(taskIDStr)
 		if err != nil {
 			h.log.Errorf("(KanbanHandlers.DeleteTask.Atoi) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, httpErrors.BadRequest, h.cfg.Http.DebugErrorsResponse)
+			return httpErrors.BadRequest
 		}
 
-		if err = h.kanbanUC.DeleteTask(c.Request().Context(), taskID); err != nil {
+		if err = h.kanbanUC.DeleteTask(c.Request().Context(), user.ID, taskID); err != nil {
 			h.log.Errorf("(kanbanUC.DeleteTask) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
 		}
 
 		return c.NoContent(http.StatusOK)
 	}
 }
 
-func (h *KanbanHandlers) ChangeDescriptionTask() echo.HandlerFunc {
+func (h *KanbanHandlers) PatchTask() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
 		taskIDStr := c.Param("task_id")
 		taskID, err := strconv.Atoi(taskIDStr)
 		if err != nil {
-			h.log.Errorf("(KanbanHandlers.ChangeDescriptionTask.Atoi) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, httpErrors.BadRequest, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(KanbanHandlers.PatchTask.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
 		}
 
-		task := &models.Task{}
-		if err := utils.ReadRequest(c, task); err != nil {
-			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+		apply, err := readPatch[models.Task](c, h.v)
+		if err != nil {
+			h.log.Errorf("(readPatch) err: {%v}", err)
+			return err
 		}
 
-		task.ID = taskID
+		task, err := h.kanbanUC.PatchTask(c.Request().Context(), user.ID, taskID, apply)
+		if err != nil {
+			h.log.Errorf("(kanbanUC.PatchTask) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, task)
+	}
+}
 
-		updatedTask, err := h.kanbanUC.ChangeDescriptionTask(c.Request().Context(), task)
+func (h *KanbanHandlers) CloneColumn() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
 		if err != nil {
-			h.log.Errorf("(kanbanUC.ChangeDescriptionTask) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
 		}
 
-		return c.JSON(http.StatusOK, updatedTask)
+		columnIDStr := c.Param("column_id")
+		columnID, err := strconv.Atoi(columnIDStr)
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.CloneColumn.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
+		}
+
+		clone, err := h.kanbanUC.CloneColumn(c.Request().Context(), user.ID, columnID)
+		if err != nil {
+			h.log.Errorf("(kanbanUC.CloneColumn) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, clone)
 	}
 }
 
-func (h *KanbanHandlers) ChangeColumnIDTask() echo.HandlerFunc {
+func (h *KanbanHandlers) CloneTask() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
 		taskIDStr := c.Param("task_id")
 		taskID, err := strconv.Atoi(taskIDStr)
 		if err != nil {
-			h.log.Errorf("(KanbanHandlers.ChangeColumnIDTask.Atoi) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, httpErrors.BadRequest, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(KanbanHandlers.CloneTask.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
 		}
 
-		task := &models.Task{}
-		if err := utils.ReadRequest(c, task); err != nil {
+		clone, err := h.kanbanUC.CloneTask(c.Request().Context(), user.ID, taskID)
+		if err != nil {
+			h.log.Errorf("(kanbanUC.CloneTask) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, clone)
+	}
+}
+
+func (h *KanbanHandlers) MoveTask() echo.HandlerFunc {
+	return h.transferTask("MoveTask", http.StatusOK, h.kanbanUC.MoveTask)
+}
+
+func (h *KanbanHandlers) CopyTask() echo.HandlerFunc {
+	return h.transferTask("CopyTask", http.StatusCreated, h.kanbanUC.CopyTask)
+}
+
+func (h *KanbanHandlers) transferTask(name string, status int, transfer func(ctx context.Context, userID int, task *models.Task) (*models.Task, error)) echo.HandlerFunc {
+	type Transfer struct {
+		ColumnID int `json:"column_id" validate:"required"`
+	}
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		taskIDStr := c.Param("task_id")
+		taskID, err := strconv.Atoi(taskIDStr)
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.%s.Atoi) err: {%v}", name, err)
+			return httpErrors.BadRequest
+		}
+
+		req := &Transfer{}
+		if err := utils.ReadRequest(c, req); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		task, err := transfer(c.Request().Context(), user.ID, &models.Task{ID: taskID, ColumnID: req.ColumnID})
+		if err != nil {
+			h.log.Errorf("(kanbanUC.%s) err: {%v}", name, err)
+			return err
+		}
+
+		return c.JSON(status, task)
+	}
+}
+
+func (h *KanbanHandlers) BulkTasks() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		bulk := &models.BulkTasks{}
+		if err := utils.ReadRequest(c, bulk); err != nil {
 			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
+		}
+
+		results, err := h.kanbanUC.BulkTasks(c.Request().Context(), user.ID, bulk)
+		if err != nil {
+			h.log.Errorf("(kanbanUC.BulkTasks) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, results)
+	}
+}
+
+func (h *KanbanHandlers) ExecuteBatch() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
 		}
 
-		task.ID = taskID
+		batch := &models.Batch{}
+		if err := utils.ReadRequest(c, batch); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
 
-		updatedTask, err := h.kanbanUC.ChangeColumnIDTask(c.Request().Context(), task)
+		result, err := h.kanbanUC.ExecuteBatch(c.Request().Context(), user.ID, batch)
 		if err != nil {
-			h.log.Errorf("(kanbanUC.ChangeColumnIDTask) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(kanbanUC.ExecuteBatch) err: {%v}", err)
+			return err
 		}
 
-		return c.JSON(http.StatusOK, updatedTask)
+		return c.JSON(http.StatusOK, result)
 	}
 }
 
+func (h *KanbanHandlers) Undo() echo.HandlerFunc {
+	return h.replayHistory("Undo", h.kanbanUC.Undo)
+}
+
+func (h *KanbanHandlers) Redo() echo.HandlerFunc {
+	return h.replayHistory("Redo", h.kanbanUC.Redo)
+}
+
+func (h *KanbanHandlers) replayHistory(name string, replay func(ctx context.Context, userID int) (*models.HistoryAction, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		action, err := replay(c.Request().Context(), user.ID)
+		if err != nil {
+			h.log.Errorf("(kanbanUC.%s) err: {%v}", name, err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, action)
+	}
+}
+
+// GetKanbanBoardByUserID returns the whole board. With a limit query parameter every
+// column holds only its first page of tasks, and fields selects the task fields to return.
 func (h *KanbanHandlers) GetKanbanBoardByUserID() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		userIDStr := c.Param("user_id")
-		userID, err := strconv.Atoi(userIDStr)
+		user, err := utils.GetUserFromCtx(c.Request().Context())
 		if err != nil {
-			h.log.Errorf("(KanbanHandlers.GetKanbanBoardByUserID.Atoi) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, httpErrors.BadRequest, h.cfg.Http.DebugErrorsResponse)
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
 		}
 
-		board, err := h.kanbanUC.GetKanbanBoardByUserID(c.Request().Context(), userID)
+		limit, err := parseLimit(c.QueryParam("limit"))
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.GetKanbanBoardByUserID.parseLimit) err: {%v}", err)
+			return err
+		}
+
+		fields, err := parseFields(c.QueryParam("fields"))
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.GetKanbanBoardByUserID.parseFields) err: {%v}", err)
+			return err
+		}
+
+		var board *models.Board
+		if limit > 0 {
+			board, err = h.kanbanUC.GetKanbanBoardPageByUserID(c.Request().Context(), user.ID, limit)
+		} else {
+			board, err = h.kanbanUC.GetKanbanBoardByUserID(c.Request().Context(), user.ID)
+		}
 		if err != nil {
 			h.log.Errorf("(kanbanUC.GetKanbanBoardByUserID) err: {%v}", err)
-			return httpErrors.ErrorCtxResponse(c, err, h.cfg.Http.DebugErrorsResponse)
+			return err
+		}
+
+		if fields != nil {
+			return c.JSON(http.StatusOK, selectBoardFields(board, fields))
 		}
 
 		return c.JSON(http.StatusOK, board)
 	}
 }
+
+// GetTasksPageByColumnID returns the column's tasks after the cursor.
+func (h *KanbanHandlers) GetTasksPageByColumnID() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		columnIDStr := c.Param("column_id")
+		columnID, err := strconv.Atoi(columnIDStr)
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.GetTasksPageByColumnID.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
+		}
+
+		limit, err := parseLimit(c.QueryParam("limit"))
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.GetTasksPageByColumnID.parseLimit) err: {%v}", err)
+			return err
+		}
+
+		fields, err := parseFields(c.QueryParam("fields"))
+		if err != nil {
+			h.log.Errorf("(KanbanHandlers.GetTasksPageByColumnID.parseFields) err: {%v}", err)
+			return err
+		}
+
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		page, err := h.kanbanUC.GetTasksPageByColumnID(c.Request().Context(), user.ID, columnID, c.QueryParam("cursor"), limit)
+		if err != nil {
+			h.log.Errorf("(kanbanUC.GetTasksPageByColumnID) err: {%v}", err)
+			return err
+		}
+
+		if fields != nil {
+			return c.JSON(http.StatusOK, &sparseTaskPage{Tasks: selectTaskFields(page.Tasks, fields), NextCursor: page.NextCursor})
+		}
+
+		return c.JSON(http.StatusOK, page)
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/aakosarev/kanban-board/back/pkg/patch"
+	"github.com/aakosarev/kanban-board/back/pkg/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"io"
+	"mime"
+)
+
+// readPatch reads a merge patch or JSON patch from the request and returns a function
+// that applies it to a resource. The patched resource must not contain unknown fields
+// and must pass the resource's validation tags.
+func readPatch[T any](c echo.Context, v *validator.Validate) (func(resource *T) error, error) {
+	contentType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil || (contentType != patch.MergePatchContentType && contentType != patch.JSONPatchContentType) {
+		return nil, errors.Wrapf(httpErrors.UnsupportedMedia, "use %s or %s", patch.MergePatchContentType, patch.JSONPatchContentType)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "readPatch.ReadAll")
+	}
+
+	return func(resource *T) error {
+		doc, err := json.Marshal(resource)
+		if err != nil {
+			return errors.Wrap(err, "readPatch.json.Marshal")
+		}
+
+		patched, err := patch.Apply(contentType, doc, body)
+		if err != nil {
+			if errors.Is(err, patch.ErrInvalidPatch) {
+				return errors.Wrap(httpErrors.BadRequest, err.Error())
+			}
+			return err
+		}
+
+		// decoding into a zero value drops the members the patch removed
+		var result T
+		decoder := json.NewDecoder(bytes.NewReader(patched))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&result); err != nil {
+			return errors.Wrap(httpErrors.BadRequest, err.Error())
+		}
+
+		if err = v.StructCtx(c.Request().Context(), &result); err != nil {
+			return validation.Translate(err, i18n.FromContext(c.Request().Context()))
+		}
+
+		*resource = result
+		return nil
+	}, nil
+}
@@ -1,14 +1,22 @@
 package http
 
 func (h *KanbanHandlers) MapRoutes() {
-	h.columnGroup.POST("/create", h.CreateColumn())
-	h.columnGroup.DELETE("/:column_id", h.DeleteColumn())
-	h.columnGroup.PATCH("/:column_id/update_name", h.ChangeNameColumn())
+	h.columnGroup.POST("/create", h.CreateColumn(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.columnGroup.DELETE("/:column_id", h.DeleteColumn(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware)
+	h.columnGroup.PATCH("/:column_id", h.PatchColumn(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware)
+	h.columnGroup.POST("/:column_id/clone", h.CloneColumn(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.columnGroup.GET("/:column_id/tasks", h.GetTasksPageByColumnID(), h.mw.AuthSessionMiddleware)
 
-	h.taskGroup.POST("/create", h.CreateTask())
-	h.taskGroup.DELETE("/:task_id", h.DeleteTask())
-	h.taskGroup.PATCH("/:task_id/update_description", h.ChangeDescriptionTask())
-	h.taskGroup.PATCH("/:task_id/update_column_id", h.ChangeColumnIDTask())
+	h.taskGroup.POST("/create", h.CreateTask(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.taskGroup.POST("/bulk", h.BulkTasks(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.taskGroup.DELETE("/:task_id", h.DeleteTask(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware)
+	h.taskGroup.PATCH("/:task_id", h.PatchTask(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware)
+	h.taskGroup.POST("/:task_id/clone", h.CloneTask(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.taskGroup.POST("/:task_id/move", h.MoveTask(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.taskGroup.POST("/:task_id/copy", h.CopyTask(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
 
-	h.boardGroup.GET("/:user_id", h.GetKanbanBoardByUserID())
+	h.boardGroup.GET("", h.GetKanbanBoardByUserID(), h.mw.AuthSessionMiddleware)
+	h.boardGroup.POST("/batch", h.ExecuteBatch(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.boardGroup.POST("/undo", h.Undo(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.boardGroup.POST("/redo", h.Redo(), h.mw.AuthSessionMiddleware, h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
 }
@@ -0,0 +1,12 @@
+package kanban
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+)
+
+type HistoryStorage interface {
+	Push(ctx context.Context, userID int, stack string, action *models.HistoryAction) error
+	Pop(ctx context.Context, userID int, stack string) (*models.HistoryAction, error)
+	Clear(ctx context.Context, userID int, stack string) error
+}
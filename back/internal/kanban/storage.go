@@ -7,13 +7,28 @@ import (
 
 type Storage interface {
 	CreateColumn(ctx context.Context, column *models.Column) (*models.Column, error)
+	GetColumnByID(ctx context.Context, id int) (*models.Column, error)
 	DeleteColumn(ctx context.Context, id int) error
 	ChangeNameColumn(ctx context.Context, column *models.Column) (*models.Column, error)
 
 	CreateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	GetTaskByID(ctx context.Context, id int) (*models.Task, error)
+	GetTasksByColumnID(ctx context.Context, columnID int) ([]*models.Task, error)
 	DeleteTask(ctx context.Context, id int) error
 	ChangeDescriptionTask(ctx context.Context, task *models.Task) (*models.Task, error)
 	ChangeColumnIDTask(ctx context.Context, task *models.Task) (*models.Task, error)
 
+	CloneColumn(ctx context.Context, id int) (*models.Clone, error)
+	CloneTask(ctx context.Context, id int) (*models.Clone, error)
+	CopyTask(ctx context.Context, task *models.Task) (*models.Task, error)
+
+	MoveTasks(ctx context.Context, ids []int, columnID int) ([]*models.Task, error)
+	DeleteTasks(ctx context.Context, ids []int) error
+
+	RestoreColumn(ctx context.Context, column *models.Column) (*models.Column, error)
+	RestoreTask(ctx context.Context, task *models.Task) (*models.Task, error)
+
 	GetKanbanBoardByUserID(ctx context.Context, userID int) (*models.Board, error)
+	GetKanbanBoardPageByUserID(ctx context.Context, userID int, limit int) (*models.Board, error)
+	GetTasksPageByColumnID(ctx context.Context, columnID int, afterID int, limit int) ([]*models.Task, error)
 }
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	boardPrefix = "kanban-board"
+)
+
+type boardCache struct {
+	redisClient *redis.Client
+	basePrefix  string
+	cfg         *config.Config
+}
+
+func NewBoardCache(redisClient *redis.Client, cfg *config.Config) kanban.BoardCache {
+	return &boardCache{redisClient: redisClient, basePrefix: boardPrefix, cfg: cfg}
+}
+
+func (s *boardCache) Version(ctx context.Context, userID int) (int64, error) {
+	version, err := s.redisClient.Get(ctx, s.createVersionKey(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "boardCache.Version.redisClient.Get")
+	}
+
+	return version, nil
+}
+
+func (s *boardCache) GetBoard(ctx context.Context, userID int, version int64) (*models.Board, error) {
+	boardBytes, err := s.redisClient.Get(ctx, s.createKey(userID, version)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "boardCache.GetBoard.redisClient.Get")
+	}
+
+	board := &models.Board{}
+	if err = json.Unmarshal(boardBytes, board); err != nil {
+		return nil, errors.Wrap(err, "boardCache.GetBoard.json.Unmarshal")
+	}
+
+	return board, nil
+}
+
+// SetBoard also extends the version key, so it always outlives the boards cached for it
+// and the version never falls back to one that still has a board.
+func (s *boardCache) SetBoard(ctx context.Context, userID int, version int64, board *models.Board) error {
+	boardBytes, err := json.Marshal(board)
+	if err != nil {
+		return errors.Wrap(err, "boardCache.SetBoard.json.Marshal")
+	}
+
+	ttl := time.Second * time.Duration(s.cfg.Redis.BoardCacheTTL)
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, s.createKey(userID, version), boardBytes, ttl)
+	pipe.Expire(ctx, s.createVersionKey(userID), 2*ttl)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "boardCache.SetBoard.pipe.Exec")
+	}
+
+	return nil
+}
+
+func (s *boardCache) Invalidate(ctx context.Context, userID int) error {
+	key := s.createVersionKey(userID)
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*time.Second*time.Duration(s.cfg.Redis.BoardCacheTTL))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "boardCache.Invalidate.pipe.Exec")
+	}
+
+	return nil
+}
+
+func (s *boardCache) createKey(userID int, version int64) string {
+	return fmt.Sprintf("%s: %d: %d", s.basePrefix, userID, version)
+}
+
+func (s *boardCache) createVersionKey(userID int) string {
+	return fmt.Sprintf("%s: %d: version", s.basePrefix, userID)
+}
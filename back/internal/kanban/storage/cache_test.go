@@ -0,0 +1,26 @@
+package storage_test
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	kanbanS "github.com/aakosarev/kanban-board/back/internal/kanban/storage"
+	"github.com/aakosarev/kanban-board/back/internal/kanban/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/redistest"
+	"testing"
+)
+
+func newCacheConfig() *config.Config {
+	return &config.Config{Redis: config.Redis{BoardCache: true, BoardCacheTTL: 60}}
+}
+
+func TestMemoryBoardCache(t *testing.T) {
+	storagetest.RunCache(t, conformance.Fresh(func() kanban.BoardCache {
+		return kanbanS.NewMemoryBoardCache(newCacheConfig())
+	}))
+}
+
+// TestRedisBoardCache runs only when REDIS_ADDR points to a Redis instance.
+func TestRedisBoardCache(t *testing.T) {
+	storagetest.RunCache(t, conformance.Shared(kanbanS.NewBoardCache(redistest.Client(t), newCacheConfig())))
+}
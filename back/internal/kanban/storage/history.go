@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	historyPrefix = "kanban-history"
+)
+
+type historyStorage struct {
+	redisClient *redis.Client
+	basePrefix  string
+	cfg         *config.Config
+}
+
+func NewHistoryStorage(redisClient *redis.Client, cfg *config.Config) kanban.HistoryStorage {
+	return &historyStorage{redisClient: redisClient, basePrefix: historyPrefix, cfg: cfg}
+}
+
+func (s *historyStorage) Push(ctx context.Context, userID int, stack string, action *models.HistoryAction) error {
+	key := s.createKey(userID, stack)
+
+	actionBytes, err := json.Marshal(action)
+	if err != nil {
+		return errors.Wrap(err, "historyStorage.Push.json.Marshal")
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.LPush(ctx, key, actionBytes)
+	pipe.LTrim(ctx, key, 0, int64(s.cfg.History.Limit-1))
+	pipe.Expire(ctx, key, time.Second*time.Duration(s.cfg.History.Expire))
+	if _, err = pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "historyStorage.Push.pipe.Exec")
+	}
+
+	return nil
+}
+
+func (s *historyStorage) Pop(ctx context.Context, userID int, stack string) (*models.HistoryAction, error) {
+	actionBytes, err := s.redisClient.LPop(ctx, s.createKey(userID, stack)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.Wrapf(domainErrors.NotFound, "historyStorage.Pop: %s stack is empty", stack)
+		}
+		return nil, errors.Wrap(err, "historyStorage.Pop.redisClient.LPop")
+	}
+
+	action := &models.HistoryAction{}
+	if err = json.Unmarshal(actionBytes, action); err != nil {
+		return nil, errors.Wrap(err, "historyStorage.Pop.json.Unmarshal")
+	}
+
+	return action, nil
+}
+
+func (s *historyStorage) Clear(ctx context.Context, userID int, stack string) error {
+	if err := s.redisClient.Del(ctx, s.createKey(userID, stack)).Err(); err != nil {
+		return errors.Wrap(err, "historyStorage.Clear")
+	}
+	return nil
+}
+
+func (s *historyStorage) createKey(userID int, stack string) string {
+	return fmt.Sprintf("%s: %d: %s", s.basePrefix, userID, stack)
+}
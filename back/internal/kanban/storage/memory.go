@@ -0,0 +1,412 @@
+package storage
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/memory"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+type memoryKanbanStorage struct {
+	db *memory.DB
+}
+
+func NewMemoryKanbanStorage(db *memory.DB) kanban.Storage {
+	return &memoryKanbanStorage{db: db}
+}
+
+func (k *memoryKanbanStorage) CreateColumn(ctx context.Context, column *models.Column) (*models.Column, error) {
+	var c models.Column
+	err := k.db.Do(ctx, func() error {
+		if _, ok := k.db.Users[column.UserID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.CreateColumn: user %d", column.UserID)
+		}
+		c = models.Column{ID: k.db.NextID(memory.ColumnTable), UserID: column.UserID, Name: column.Name}
+		k.db.Columns[c.ID] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (k *memoryKanbanStorage) GetColumnByID(ctx context.Context, id int) (*models.Column, error) {
+	var c models.Column
+	err := k.db.Do(ctx, func() error {
+		var ok bool
+		if c, ok = k.db.Columns[id]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.GetColumnByID")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (k *memoryKanbanStorage) DeleteColumn(ctx context.Context, id int) error {
+	return k.db.Do(ctx, func() error {
+		if _, ok := k.db.Columns[id]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.DeleteColumn")
+		}
+		delete(k.db.Columns, id)
+		for taskID, t := range k.db.Tasks {
+			if t.ColumnID == id {
+				delete(k.db.Tasks, taskID)
+			}
+		}
+		return nil
+	})
+}
+
+func (k *memoryKanbanStorage) ChangeNameColumn(ctx context.Context, column *models.Column) (*models.Column, error) {
+	var c models.Column
+	err := k.db.Do(ctx, func() error {
+		var ok bool
+		if c, ok = k.db.Columns[column.ID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.ChangeNameColumn")
+		}
+		c.Name = column.Name
+		k.db.Columns[c.ID] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (k *memoryKanbanStorage) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var t models.Task
+	err := k.db.Do(ctx, func() error {
+		if _, ok := k.db.Columns[task.ColumnID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.CreateTask: column %d", task.ColumnID)
+		}
+		t = models.Task{ID: k.db.NextID(memory.TaskTable), ColumnID: task.ColumnID, Description: task.Description}
+		k.db.Tasks[t.ID] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (k *memoryKanbanStorage) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
+	var t models.Task
+	err := k.db.Do(ctx, func() error {
+		var ok bool
+		if t, ok = k.db.Tasks[id]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.GetTaskByID")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (k *memoryKanbanStorage) GetTasksByColumnID(ctx context.Context, columnID int) ([]*models.Task, error) {
+	tasks := make([]*models.Task, 0)
+	err := k.db.Do(ctx, func() error {
+		for _, t := range k.db.Tasks {
+			if t.ColumnID == columnID {
+				t := t
+				tasks = append(tasks, &t)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortTasks(tasks)
+
+	return tasks, nil
+}
+
+func (k *memoryKanbanStorage) DeleteTask(ctx context.Context, id int) error {
+	return k.db.Do(ctx, func() error {
+		if _, ok := k.db.Tasks[id]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.DeleteTask")
+		}
+		delete(k.db.Tasks, id)
+		return nil
+	})
+}
+
+func (k *memoryKanbanStorage) ChangeDescriptionTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var t models.Task
+	err := k.db.Do(ctx, func() error {
+		var ok bool
+		if t, ok = k.db.Tasks[task.ID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.ChangeDescriptionTask")
+		}
+		t.Description = task.Description
+		k.db.Tasks[t.ID] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (k *memoryKanbanStorage) ChangeColumnIDTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var t models.Task
+	err := k.db.Do(ctx, func() error {
+		var ok bool
+		if t, ok = k.db.Tasks[task.ID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.ChangeColumnIDTask")
+		}
+		if _, ok = k.db.Columns[task.ColumnID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.ChangeColumnIDTask: column %d", task.ColumnID)
+		}
+		t.ColumnID = task.ColumnID
+		k.db.Tasks[t.ID] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (k *memoryKanbanStorage) CloneColumn(ctx context.Context, id int) (*models.Clone, error) {
+	clone := models.NewClone()
+	err := k.db.Do(ctx, func() error {
+		c, ok := k.db.Columns[id]
+		if !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.CloneColumn")
+		}
+		c.ID = k.db.NextID(memory.ColumnTable)
+		k.db.Columns[c.ID] = c
+		clone.Columns[id] = c.ID
+
+		for _, t := range k.tasksByColumnID(id) {
+			taskID := t.ID
+			t.ID = k.db.NextID(memory.TaskTable)
+			t.ColumnID = c.ID
+			k.db.Tasks[t.ID] = *t
+			clone.Tasks[taskID] = t.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+func (k *memoryKanbanStorage) CloneTask(ctx context.Context, id int) (*models.Clone, error) {
+	clone := models.NewClone()
+	err := k.db.Do(ctx, func() error {
+		t, ok := k.db.Tasks[id]
+		if !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.CloneTask")
+		}
+		t.ID = k.db.NextID(memory.TaskTable)
+		k.db.Tasks[t.ID] = t
+		clone.Tasks[id] = t.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+func (k *memoryKanbanStorage) CopyTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var t models.Task
+	err := k.db.Do(ctx, func() error {
+		var ok bool
+		if t, ok = k.db.Tasks[task.ID]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryKanbanStorage.CopyTask")
+		}
+		if _, ok = k.db.Columns[task.ColumnID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.CopyTask: column %d", task.ColumnID)
+		}
+		t.ID = k.db.NextID(memory.TaskTable)
+		t.ColumnID = task.ColumnID
+		k.db.Tasks[t.ID] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (k *memoryKanbanStorage) MoveTasks(ctx context.Context, ids []int, columnID int) ([]*models.Task, error) {
+	tasks := make([]*models.Task, 0, len(ids))
+	err := k.db.Do(ctx, func() error {
+		if _, ok := k.db.Columns[columnID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.MoveTasks: column %d", columnID)
+		}
+		for _, id := range ids {
+			t, ok := k.db.Tasks[id]
+			if !ok {
+				continue
+			}
+			t.ColumnID = columnID
+			k.db.Tasks[id] = t
+			tasks = append(tasks, &t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (k *memoryKanbanStorage) DeleteTasks(ctx context.Context, ids []int) error {
+	return k.db.Do(ctx, func() error {
+		for _, id := range ids {
+			delete(k.db.Tasks, id)
+		}
+		return nil
+	})
+}
+
+func (k *memoryKanbanStorage) RestoreColumn(ctx context.Context, column *models.Column) (*models.Column, error) {
+	c := *column
+	err := k.db.Do(ctx, func() error {
+		if _, ok := k.db.Columns[c.ID]; ok {
+			return errors.Wrapf(domainErrors.Conflict, "memoryKanbanStorage.RestoreColumn: column %d", c.ID)
+		}
+		if _, ok := k.db.Users[c.UserID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.RestoreColumn: user %d", c.UserID)
+		}
+		k.db.Reserve(memory.ColumnTable, c.ID)
+		k.db.Columns[c.ID] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (k *memoryKanbanStorage) RestoreTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	t := *task
+	err := k.db.Do(ctx, func() error {
+		if _, ok := k.db.Tasks[t.ID]; ok {
+			return errors.Wrapf(domainErrors.Conflict, "memoryKanbanStorage.RestoreTask: task %d", t.ID)
+		}
+		if _, ok := k.db.Columns[t.ColumnID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryKanbanStorage.RestoreTask: column %d", t.ColumnID)
+		}
+		k.db.Reserve(memory.TaskTable, t.ID)
+		k.db.Tasks[t.ID] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (k *memoryKanbanStorage) GetKanbanBoardByUserID(ctx context.Context, userID int) (*models.Board, error) {
+	b := &models.Board{Columns: make([]*models.Col, 0)}
+	err := k.db.Do(ctx, func() error {
+		columns := make(map[int]*models.Col)
+		for _, c := range k.db.Columns {
+			if c.UserID != userID {
+				continue
+			}
+			col := &models.Col{ID: c.ID, Name: c.Name, Tasks: make([]*models.T, 0)}
+			columns[c.ID] = col
+			b.Columns = append(b.Columns, col)
+		}
+
+		for _, t := range k.db.Tasks {
+			if col, ok := columns[t.ColumnID]; ok {
+				col.Tasks = append(col.Tasks, &models.T{ID: t.ID, ColumnID: t.ColumnID, Description: t.Description})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(b.Columns, func(i, j int) bool { return b.Columns[i].ID < b.Columns[j].ID })
+	for _, col := range b.Columns {
+		tasks := col.Tasks
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	}
+
+	return b, nil
+}
+
+func (k *memoryKanbanStorage) GetKanbanBoardPageByUserID(ctx context.Context, userID int, limit int) (*models.Board, error) {
+	b, err := k.GetKanbanBoardByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range b.Columns {
+		if len(col.Tasks) > limit {
+			col.Tasks = col.Tasks[:limit]
+		}
+	}
+
+	return b, nil
+}
+
+func (k *memoryKanbanStorage) GetTasksPageByColumnID(ctx context.Context, columnID int, afterID int, limit int) ([]*models.Task, error) {
+	tasks, err := k.GetTasksByColumnID(ctx, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := make([]*models.Task, 0, limit)
+	for _, t := range tasks {
+		if len(page) == limit {
+			break
+		}
+		if t.ID > afterID {
+			page = append(page, t)
+		}
+	}
+
+	return page, nil
+}
+
+// tasksByColumnID must be called with the DB locked.
+func (k *memoryKanbanStorage) tasksByColumnID(columnID int) []*models.Task {
+	tasks := make([]*models.Task, 0)
+	for _, t := range k.db.Tasks {
+		if t.ColumnID == columnID {
+			t := t
+			tasks = append(tasks, &t)
+		}
+	}
+	sortTasks(tasks)
+	return tasks
+}
+
+func sortTasks(tasks []*models.Task) {
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+type cachedBoard struct {
+	version  int64
+	board    []byte
+	expireAt time.Time
+}
+
+type memoryBoardCache struct {
+	mu       sync.Mutex
+	versions map[int]int64
+	boards   map[int]cachedBoard
+	cfg      *config.Config
+}
+
+// NewMemoryBoardCache keeps one serialized board per user, so a cached board never
+// shares memory with the boards it hands out.
+func NewMemoryBoardCache(cfg *config.Config) kanban.BoardCache {
+	return &memoryBoardCache{versions: make(map[int]int64), boards: make(map[int]cachedBoard), cfg: cfg}
+}
+
+func (s *memoryBoardCache) Version(ctx context.Context, userID int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.versions[userID], nil
+}
+
+func (s *memoryBoardCache) GetBoard(ctx context.Context, userID int, version int64) (*models.Board, error) {
+	s.mu.Lock()
+	cached, ok := s.boards[userID]
+	s.mu.Unlock()
+
+	if !ok || cached.version != version || time.Now().After(cached.expireAt) {
+		return nil, nil
+	}
+
+	board := &models.Board{}
+	if err := json.Unmarshal(cached.board, board); err != nil {
+		return nil, errors.Wrap(err, "memoryBoardCache.GetBoard.json.Unmarshal")
+	}
+
+	return board, nil
+}
+
+func (s *memoryBoardCache) SetBoard(ctx context.Context, userID int, version int64, board *models.Board) error {
+	boardBytes, err := json.Marshal(board)
+	if err != nil {
+		return errors.Wrap(err, "memoryBoardCache.SetBoard.json.Marshal")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a board read before an invalidation must not replace a newer one
+	if version != s.versions[userID] {
+		return nil
+	}
+
+	s.boards[userID] = cachedBoard{
+		version:  version,
+		board:    boardBytes,
+		expireAt: time.Now().Add(time.Second * time.Duration(s.cfg.Redis.BoardCacheTTL)),
+	}
+
+	return nil
+}
+
+func (s *memoryBoardCache) Invalidate(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions[userID]++
+	delete(s.boards, userID)
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+type historyStack struct {
+	actions  []*models.HistoryAction
+	expireAt time.Time
+}
+
+type memoryHistoryStorage struct {
+	mu     sync.Mutex
+	stacks map[string]*historyStack
+	cfg    *config.Config
+}
+
+func NewMemoryHistoryStorage(cfg *config.Config) kanban.HistoryStorage {
+	return &memoryHistoryStorage{stacks: make(map[string]*historyStack), cfg: cfg}
+}
+
+func (s *memoryHistoryStorage) Push(ctx context.Context, userID int, stack string, action *models.HistoryAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.createKey(userID, stack)
+	hs := s.stack(key)
+	if hs == nil {
+		hs = &historyStack{}
+		s.stacks[key] = hs
+	}
+
+	hs.actions = append([]*models.HistoryAction{action}, hs.actions...)
+	if len(hs.actions) > s.cfg.History.Limit {
+		hs.actions = hs.actions[:s.cfg.History.Limit]
+	}
+	hs.expireAt = time.Now().Add(time.Second * time.Duration(s.cfg.History.Expire))
+
+	return nil
+}
+
+func (s *memoryHistoryStorage) Pop(ctx context.Context, userID int, stack string) (*models.HistoryAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hs := s.stack(s.createKey(userID, stack))
+	if hs == nil || len(hs.actions) == 0 {
+		return nil, errors.Wrapf(domainErrors.NotFound, "memoryHistoryStorage.Pop: %s stack is empty", stack)
+	}
+
+	action := hs.actions[0]
+	hs.actions = hs.actions[1:]
+
+	return action, nil
+}
+
+func (s *memoryHistoryStorage) Clear(ctx context.Context, userID int, stack string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.stacks, s.createKey(userID, stack))
+
+	return nil
+}
+
+// stack returns the stack stored under key, dropping it once it has expired.
+func (s *memoryHistoryStorage) stack(key string) *historyStack {
+	hs, ok := s.stacks[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(hs.expireAt) {
+		delete(s.stacks, key)
+		return nil
+	}
+	return hs
+}
+
+func (s *memoryHistoryStorage) createKey(userID int, stack string) string {
+	return fmt.Sprintf("%d: %s", userID, stack)
+}
@@ -0,0 +1,26 @@
+package storage_test
+
+import (
+	authS "github.com/aakosarev/kanban-board/back/internal/auth/storage"
+	kanbanS "github.com/aakosarev/kanban-board/back/internal/kanban/storage"
+	"github.com/aakosarev/kanban-board/back/internal/kanban/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/memory"
+	"testing"
+)
+
+func newMemoryStorages() storagetest.Storages {
+	db := memory.NewDB()
+	return storagetest.Storages{
+		Kanban: kanbanS.NewMemoryKanbanStorage(db),
+		Auth:   authS.NewMemoryAuthStorage(db),
+	}
+}
+
+func TestMemoryKanbanStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Fresh(newMemoryStorages))
+}
+
+func BenchmarkMemoryBoard(b *testing.B) {
+	storagetest.BenchmarkBoard(b, newMemoryStorages(), 20, 250)
+}
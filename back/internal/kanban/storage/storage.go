@@ -2,23 +2,27 @@ package storage
 
 import (
 	"context"
-	"database/sql"
 	"github.com/aakosarev/kanban-board/back/internal/kanban"
 	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/aakosarev/kanban-board/back/pkg/postgres"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
+	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 )
 
 type KanbanStorage struct {
-	log    logger.Logger
-	client *pgxpool.Pool
+	log       logger.Logger
+	client    postgres.Client
+	txManager transaction.Manager
 }
 
-func NewKanbanStorage(log logger.Logger, client *pgxpool.Pool) kanban.Storage {
+func NewKanbanStorage(log logger.Logger, client postgres.Client) kanban.Storage {
 	return &KanbanStorage{
-		log:    log,
-		client: client,
+		log:       log,
+		client:    client,
+		txManager: postgres.NewTxManager(client),
 	}
 }
 
@@ -31,7 +35,26 @@ func (k *KanbanStorage) CreateColumn(ctx context.Context, column *models.Column)
 
 	c := &models.Column{}
 
-	if err := k.client.QueryRow(ctx, query, column.UserID, column.Name).Scan(&c.ID, &c.UserID, &c.Name); err != nil {
+	if err := k.conn(ctx).QueryRow(ctx, query, column.UserID, column.Name).Scan(&c.ID, &c.UserID, &c.Name); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (k *KanbanStorage) GetColumnByID(ctx context.Context, id int) (*models.Column, error) {
+	query := `
+		SELECT *
+		FROM "column"
+		WHERE id = $1;
+	`
+
+	c := &models.Column{}
+
+	if err := k.conn(ctx).QueryRow(ctx, query, id).Scan(&c.ID, &c.UserID, &c.Name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.GetColumnByID.Scan")
+		}
 		return nil, err
 	}
 
@@ -44,13 +67,13 @@ func (k *KanbanStorage) DeleteColumn(ctx context.Context, id int) error {
 		WHERE id = $1;
 	`
 
-	res, err := k.client.Exec(ctx, query, id)
+	res, err := k.conn(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return errors.Wrap(err, "KanbanStorage.DeleteColumn.Exec")
 	}
 
 	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
-		return errors.Wrap(sql.ErrNoRows, "KanbanStorage.DeleteColumn.rowsAffected")
+		return errors.Wrap(domainErrors.NotFound, "KanbanStorage.DeleteColumn.rowsAffected")
 	}
 
 	return nil
@@ -66,7 +89,10 @@ func (k *KanbanStorage) ChangeNameColumn(ctx context.Context, column *models.Col
 
 	c := &models.Column{}
 
-	if err := k.client.QueryRow(ctx, query, column.Name, column.ID).Scan(&c.ID, &c.UserID, &c.Name); err != nil {
+	if err := k.conn(ctx).QueryRow(ctx, query, column.Name, column.ID).Scan(&c.ID, &c.UserID, &c.Name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.ChangeNameColumn.Scan")
+		}
 		return nil, err
 	}
 
@@ -82,26 +108,66 @@ func (k *KanbanStorage) CreateTask(ctx context.Context, task *models.Task) (*mod
 
 	t := &models.Task{}
 
-	if err := k.client.QueryRow(ctx, query, task.ColumnID, task.Description).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+	if err := k.conn(ctx).QueryRow(ctx, query, task.ColumnID, task.Description).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
 		return nil, err
 	}
 
 	return t, nil
 }
 
+func (k *KanbanStorage) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
+	query := `
+		SELECT *
+		FROM "task"
+		WHERE id = $1;
+	`
+
+	t := &models.Task{}
+
+	if err := k.conn(ctx).QueryRow(ctx, query, id).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.GetTaskByID.Scan")
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (k *KanbanStorage) GetTasksByColumnID(ctx context.Context, columnID int) ([]*models.Task, error) {
+	query := `
+		SELECT *
+		FROM "task"
+		WHERE column_id = $1
+		ORDER BY id;
+	`
+
+	rows, err := k.conn(ctx).Query(ctx, query, columnID)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetTasksByColumnID.Query")
+	}
+
+	tasks, err := pgx.CollectRows(rows, scanTask)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetTasksByColumnID.CollectRows")
+	}
+
+	return tasks, nil
+}
+
 func (k *KanbanStorage) DeleteTask(ctx context.Context, id int) error {
 	query := `
 		DELETE FROM "task"
 		WHERE id = $1;
 	`
 
-	res, err := k.client.Exec(ctx, query, id)
+	res, err := k.conn(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return errors.Wrap(err, "KanbanStorage.DeleteTask.Exec")
 	}
 
 	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
-		return errors.Wrap(sql.ErrNoRows, "KanbanStorage.DeleteTask.rowsAffected")
+		return errors.Wrap(domainErrors.NotFound, "KanbanStorage.DeleteTask.rowsAffected")
 	}
 
 	return nil
@@ -117,7 +183,10 @@ func (k *KanbanStorage) ChangeDescriptionTask(ctx context.Context, task *models.
 
 	t := &models.Task{}
 
-	if err := k.client.QueryRow(ctx, query, task.Description, task.ID).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+	if err := k.conn(ctx).QueryRow(ctx, query, task.Description, task.ID).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.ChangeDescriptionTask.Scan")
+		}
 		return nil, err
 	}
 
@@ -134,61 +203,295 @@ func (k *KanbanStorage) ChangeColumnIDTask(ctx context.Context, task *models.Tas
 
 	t := &models.Task{}
 
-	if err := k.client.QueryRow(ctx, query, task.ColumnID, task.ID).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+	if err := k.conn(ctx).QueryRow(ctx, query, task.ColumnID, task.ID).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.ChangeColumnIDTask.Scan")
+		}
 		return nil, err
 	}
 
 	return t, nil
 }
 
+func (k *KanbanStorage) CloneColumn(ctx context.Context, id int) (*models.Clone, error) {
+	columnQuery := `
+		INSERT INTO "column"(user_id, name)
+		SELECT user_id, name
+		FROM "column"
+		WHERE id = $1
+		RETURNING id;
+	`
+	tasksQuery := `
+		SELECT id
+		FROM "task"
+		WHERE column_id = $1
+		ORDER BY id;
+	`
+	taskQuery := `
+		INSERT INTO "task"(column_id, description)
+		SELECT $2, description
+		FROM "task"
+		WHERE id = $1
+		RETURNING id;
+	`
+
+	clone := models.NewClone()
+
+	err := k.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var columnID int
+		if err := k.conn(ctx).QueryRow(ctx, columnQuery, id).Scan(&columnID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errors.Wrap(domainErrors.NotFound, "KanbanStorage.CloneColumn.column")
+			}
+			return errors.Wrap(err, "KanbanStorage.CloneColumn.column")
+		}
+		clone.Columns[id] = columnID
+
+		rows, err := k.conn(ctx).Query(ctx, tasksQuery, id)
+		if err != nil {
+			return errors.Wrap(err, "KanbanStorage.CloneColumn.Query")
+		}
+		taskIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return errors.Wrap(err, "KanbanStorage.CloneColumn.CollectRows")
+		}
+
+		for _, taskID := range taskIDs {
+			var clonedTaskID int
+			if err := k.conn(ctx).QueryRow(ctx, taskQuery, taskID, columnID).Scan(&clonedTaskID); err != nil {
+				return errors.Wrap(err, "KanbanStorage.CloneColumn.task")
+			}
+			clone.Tasks[taskID] = clonedTaskID
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+func (k *KanbanStorage) CloneTask(ctx context.Context, id int) (*models.Clone, error) {
+	query := `
+		INSERT INTO "task"(column_id, description)
+		SELECT column_id, description
+		FROM "task"
+		WHERE id = $1
+		RETURNING id;
+	`
+
+	var taskID int
+
+	if err := k.conn(ctx).QueryRow(ctx, query, id).Scan(&taskID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.CloneTask.Scan")
+		}
+		return nil, err
+	}
+
+	clone := models.NewClone()
+	clone.Tasks[id] = taskID
+
+	return clone, nil
+}
+
+func (k *KanbanStorage) CopyTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	query := `
+		INSERT INTO "task"(column_id, description)
+		SELECT $1, description
+		FROM "task"
+		WHERE id = $2
+		RETURNING *;
+	`
+
+	t := &models.Task{}
+
+	if err := k.conn(ctx).QueryRow(ctx, query, task.ColumnID, task.ID).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "KanbanStorage.CopyTask.Scan")
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (k *KanbanStorage) MoveTasks(ctx context.Context, ids []int, columnID int) ([]*models.Task, error) {
+	query := `
+		UPDATE "task"
+		SET column_id = $1
+		WHERE id = ANY($2)
+		RETURNING *;
+	`
+
+	rows, err := k.conn(ctx).Query(ctx, query, columnID, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.MoveTasks.Query")
+	}
+
+	tasks, err := pgx.CollectRows(rows, scanTask)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.MoveTasks.CollectRows")
+	}
+
+	return tasks, nil
+}
+
+func (k *KanbanStorage) DeleteTasks(ctx context.Context, ids []int) error {
+	query := `
+		DELETE FROM "task"
+		WHERE id = ANY($1);
+	`
+
+	if _, err := k.conn(ctx).Exec(ctx, query, ids); err != nil {
+		return errors.Wrap(err, "KanbanStorage.DeleteTasks.Exec")
+	}
+
+	return nil
+}
+
+// RestoreColumn inserts a previously deleted column under its original ID.
+func (k *KanbanStorage) RestoreColumn(ctx context.Context, column *models.Column) (*models.Column, error) {
+	query := `
+		INSERT INTO "column"(id, user_id, name)
+		VALUES ($1, $2, $3)
+		RETURNING *;
+	`
+
+	c := &models.Column{}
+
+	if err := k.conn(ctx).QueryRow(ctx, query, column.ID, column.UserID, column.Name).Scan(&c.ID, &c.UserID, &c.Name); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// RestoreTask inserts a previously deleted task under its original ID.
+func (k *KanbanStorage) RestoreTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	query := `
+		INSERT INTO "task"(id, column_id, description)
+		VALUES ($1, $2, $3)
+		RETURNING *;
+	`
+
+	t := &models.Task{}
+
+	if err := k.conn(ctx).QueryRow(ctx, query, task.ID, task.ColumnID, task.Description).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (k *KanbanStorage) conn(ctx context.Context) postgres.Querier {
+	return postgres.Conn(ctx, k.client)
+}
+
+func scanTask(row pgx.CollectableRow) (*models.Task, error) {
+	t := &models.Task{}
+	return t, row.Scan(&t.ID, &t.ColumnID, &t.Description)
+}
+
+// GetKanbanBoardByUserID reads the whole board in one query. Tasks are aggregated per
+// column, so empty columns come back with an empty task list instead of a NULL row.
 func (k *KanbanStorage) GetKanbanBoardByUserID(ctx context.Context, userID int) (*models.Board, error) {
 	query := `
-		SELECT 
-		    "column".id AS column_id, 
-		    "column".name AS column_name,
-		    "task".id AS task_id,
-		    "task".column_id AS task_column_id,
-		    "task".description AS task_description
+		SELECT
+		    "column".id,
+		    COALESCE("column".name, ''),
+		    COALESCE(
+		        json_agg(
+		            json_build_object('id', "task".id, 'column_id', "task".column_id, 'description', "task".description)
+		            ORDER BY "task".id
+		        ) FILTER (WHERE "task".id IS NOT NULL),
+		        '[]'
+		    )
 		FROM "column"
 		LEFT JOIN "task" ON "column".id = "task".column_id
 		WHERE "column".user_id = $1
-		ORDER BY column_id;
+		GROUP BY "column".id
+		ORDER BY "column".id;
 	`
 
-	rows, err := k.client.Query(ctx, query, userID)
+	rows, err := k.conn(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, errors.Wrap(err, "KanbanStorage.GetKanbanBoardByUserID.Query")
 	}
-	defer rows.Close()
 
-	b := models.Board{}
-	columnsMap := make(map[int32]*models.Col)
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Col, error) {
+		col := &models.Col{}
+		return col, row.Scan(&col.ID, &col.Name, &col.Tasks)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetKanbanBoardByUserID.CollectRows")
+	}
 
-	for rows.Next() {
-		k.log.Debug(1)
-		var colID, taskID, taskColumnID sql.NullInt32
-		var colName, taskDesc sql.NullString
-		if err := rows.Scan(&colID, &colName, &taskID, &taskColumnID, &taskDesc); err != nil {
-			return nil, errors.Wrap(err, "KanbanStorage.GetKanbanBoardByUserID.Scan")
-		}
-		col, exists := columnsMap[colID.Int32]
-		if !exists {
-			col = &models.Col{
-				ID:   int(colID.Int32),
-				Name: colName.String,
-			}
-			columnsMap[colID.Int32] = col
-			b.Columns = append(b.Columns, col)
-		}
+	return &models.Board{Columns: columns}, nil
+}
 
-		task := models.T{
-			ID:          int(taskID.Int32),
-			ColumnID:    int(taskColumnID.Int32),
-			Description: taskDesc.String,
-		}
-		col.Tasks = append(col.Tasks, &task)
+// GetKanbanBoardPageByUserID works like GetKanbanBoardByUserID, but returns at most
+// limit tasks of every column.
+func (k *KanbanStorage) GetKanbanBoardPageByUserID(ctx context.Context, userID int, limit int) (*models.Board, error) {
+	query := `
+		SELECT
+		    "column".id,
+		    COALESCE("column".name, ''),
+		    COALESCE(page.tasks, '[]')
+		FROM "column"
+		LEFT JOIN LATERAL (
+		    SELECT json_agg(
+		        json_build_object('id', t.id, 'column_id', t.column_id, 'description', t.description)
+		        ORDER BY t.id
+		    ) AS tasks
+		    FROM (
+		        SELECT id, column_id, description
+		        FROM "task"
+		        WHERE column_id = "column".id
+		        ORDER BY id
+		        LIMIT $2
+		    ) t
+		) page ON true
+		WHERE "column".user_id = $1
+		ORDER BY "column".id;
+	`
 
+	rows, err := k.conn(ctx).Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetKanbanBoardPageByUserID.Query")
 	}
-	k.log.Debug(b)
-	return &b, nil
+
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Col, error) {
+		col := &models.Col{}
+		return col, row.Scan(&col.ID, &col.Name, &col.Tasks)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetKanbanBoardPageByUserID.CollectRows")
+	}
+
+	return &models.Board{Columns: columns}, nil
+}
+
+func (k *KanbanStorage) GetTasksPageByColumnID(ctx context.Context, columnID int, afterID int, limit int) ([]*models.Task, error) {
+	query := `
+		SELECT id, column_id, description
+		FROM "task"
+		WHERE column_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3;
+	`
+
+	rows, err := k.conn(ctx).Query(ctx, query, columnID, afterID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetTasksPageByColumnID.Query")
+	}
+
+	tasks, err := pgx.CollectRows(rows, scanTask)
+	if err != nil {
+		return nil, errors.Wrap(err, "KanbanStorage.GetTasksPageByColumnID.CollectRows")
+	}
+
+	return tasks, nil
 }
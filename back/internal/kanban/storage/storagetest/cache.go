@@ -0,0 +1,125 @@
+package storagetest
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+)
+
+// RunCache runs the suite against the board cache returned by newCache, which is called
+// once per test.
+func RunCache(t *testing.T, newCache func(t *testing.T) kanban.BoardCache) {
+	conformance.Run(t, newCache, []conformance.Test[kanban.BoardCache]{
+		{Name: "Miss", Fn: testCacheMiss},
+		{Name: "SetAndGet", Fn: testCacheSetAndGet},
+		{Name: "Invalidate", Fn: testCacheInvalidate},
+		{Name: "UsersAreSeparate", Fn: testCacheUsersAreSeparate},
+	}...)
+}
+
+func testCacheMiss(ctx context.Context, t *testing.T, c kanban.BoardCache) {
+	userID := cacheUser()
+
+	version, err := c.Version(ctx, userID)
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if version != 0 {
+		t.Fatalf("Version of a new user = %d, want 0", version)
+	}
+
+	board, err := c.GetBoard(ctx, userID, version)
+	if err != nil || board != nil {
+		t.Fatalf("GetBoard of a new user = %+v, %v, want a miss", board, err)
+	}
+}
+
+func testCacheSetAndGet(ctx context.Context, t *testing.T, c kanban.BoardCache) {
+	userID := cacheUser()
+	want := cacheBoard()
+
+	version, err := c.Version(ctx, userID)
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if err = c.SetBoard(ctx, userID, version, want); err != nil {
+		t.Fatalf("SetBoard: %v", err)
+	}
+
+	got, err := c.GetBoard(ctx, userID, version)
+	if err != nil {
+		t.Fatalf("GetBoard: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetBoard = %+v, want %+v", got, want)
+	}
+}
+
+func testCacheInvalidate(ctx context.Context, t *testing.T, c kanban.BoardCache) {
+	userID := cacheUser()
+
+	before, err := c.Version(ctx, userID)
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if err = c.SetBoard(ctx, userID, before, cacheBoard()); err != nil {
+		t.Fatalf("SetBoard: %v", err)
+	}
+
+	if err = c.Invalidate(ctx, userID); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+
+	after, err := c.Version(ctx, userID)
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if after == before {
+		t.Fatalf("Version after Invalidate = %d, want a new version", after)
+	}
+	if board, err := c.GetBoard(ctx, userID, after); err != nil || board != nil {
+		t.Fatalf("GetBoard after Invalidate = %+v, %v, want a miss", board, err)
+	}
+
+	if err = c.Invalidate(ctx, userID); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if again, err := c.Version(ctx, userID); err != nil || again == after || again == before {
+		t.Fatalf("Version after the second Invalidate = %d, %v, want a new version", again, err)
+	}
+}
+
+func testCacheUsersAreSeparate(ctx context.Context, t *testing.T, c kanban.BoardCache) {
+	userID, otherID := cacheUser(), cacheUser()
+
+	if err := c.SetBoard(ctx, userID, 0, cacheBoard()); err != nil {
+		t.Fatalf("SetBoard: %v", err)
+	}
+	if err := c.Invalidate(ctx, otherID); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+
+	if version, err := c.Version(ctx, userID); err != nil || version != 0 {
+		t.Fatalf("Version after invalidating another user = %d, %v, want 0", version, err)
+	}
+	if board, err := c.GetBoard(ctx, userID, 0); err != nil || board == nil {
+		t.Fatalf("GetBoard after invalidating another user = %+v, %v, want a hit", board, err)
+	}
+}
+
+// cacheUser returns a user ID that is unique across runs, so the cache suite can share a
+// Redis instance that is not flushed between tests.
+func cacheUser() int {
+	return int(uuid.New().ID())
+}
+
+func cacheBoard() *models.Board {
+	return &models.Board{Columns: []*models.Col{
+		{ID: 1, Name: "todo", Tasks: []*models.T{{ID: 1, ColumnID: 1, Description: "task"}}},
+		{ID: 2, Name: "done", Tasks: []*models.T{}},
+	}}
+}
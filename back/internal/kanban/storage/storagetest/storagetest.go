@@ -0,0 +1,517 @@
+// Package storagetest is a conformance suite for kanban.Storage implementations.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	authStoragetest "github.com/aakosarev/kanban-board/back/internal/auth/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+// Storages are the storages under test. Auth creates the users that own columns and
+// must share its database with Kanban.
+type Storages struct {
+	Kanban kanban.Storage
+	Auth   auth.Storage
+}
+
+type suite struct {
+	t *testing.T
+	Storages
+}
+
+// Run runs the suite against the storages returned by newStorages, which is called
+// once per test.
+func Run(t *testing.T, newStorages func(t *testing.T) Storages) {
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, s *suite)
+	}{
+		{"CreateColumn", testCreateColumn},
+		{"ChangeNameColumn", testChangeNameColumn},
+		{"DeleteColumn", testDeleteColumn},
+		{"CreateTask", testCreateTask},
+		{"ChangeTask", testChangeTask},
+		{"MoveTasks", testMoveTasks},
+		{"DeleteTasks", testDeleteTasks},
+		{"CloneColumn", testCloneColumn},
+		{"Restore", testRestore},
+		{"RestoreConflict", testRestoreConflict},
+		{"Board", testBoard},
+		{"EmptyBoard", testEmptyBoard},
+		{"BoardPage", testBoardPage},
+		{"TasksPage", testTasksPage},
+		{"NotFound", testNotFound},
+	}
+
+	cases := make([]conformance.Test[Storages], 0, len(tests))
+	for _, tt := range tests {
+		fn := tt.fn
+		cases = append(cases, conformance.Test[Storages]{Name: tt.name, Fn: func(ctx context.Context, t *testing.T, storages Storages) {
+			fn(ctx, &suite{t: t, Storages: storages})
+		}})
+	}
+
+	conformance.Run(t, newStorages, cases...)
+}
+
+func testCreateColumn(ctx context.Context, s *suite) {
+	userID := s.user(ctx)
+
+	c, err := s.Kanban.CreateColumn(ctx, &models.Column{UserID: userID, Name: "todo"})
+	if err != nil {
+		s.t.Fatalf("CreateColumn: %v", err)
+	}
+	if c.ID == 0 || c.UserID != userID || c.Name != "todo" {
+		s.t.Fatalf("CreateColumn = %+v", c)
+	}
+
+	found, err := s.Kanban.GetColumnByID(ctx, c.ID)
+	if err != nil {
+		s.t.Fatalf("GetColumnByID: %v", err)
+	}
+	if *found != *c {
+		s.t.Fatalf("GetColumnByID = %+v, want %+v", found, c)
+	}
+
+	if _, err = s.Kanban.CreateColumn(ctx, &models.Column{UserID: -1, Name: "orphan"}); err == nil {
+		s.t.Fatal("CreateColumn for a missing user succeeded")
+	}
+}
+
+func testChangeNameColumn(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+
+	renamed, err := s.Kanban.ChangeNameColumn(ctx, &models.Column{ID: c.ID, Name: "done"})
+	if err != nil {
+		s.t.Fatalf("ChangeNameColumn: %v", err)
+	}
+	if renamed.ID != c.ID || renamed.UserID != c.UserID || renamed.Name != "done" {
+		s.t.Fatalf("ChangeNameColumn = %+v", renamed)
+	}
+
+	found, err := s.Kanban.GetColumnByID(ctx, c.ID)
+	if err != nil {
+		s.t.Fatalf("GetColumnByID: %v", err)
+	}
+	if found.Name != "done" {
+		s.t.Fatalf("GetColumnByID name = %q, want %q", found.Name, "done")
+	}
+}
+
+func testDeleteColumn(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+	task := s.task(ctx, c.ID, "task")
+
+	if err := s.Kanban.DeleteColumn(ctx, c.ID); err != nil {
+		s.t.Fatalf("DeleteColumn: %v", err)
+	}
+
+	if _, err := s.Kanban.GetColumnByID(ctx, c.ID); !errors.Is(err, domainErrors.NotFound) {
+		s.t.Errorf("GetColumnByID after delete err = %v, want domainErrors.NotFound", err)
+	}
+	if _, err := s.Kanban.GetTaskByID(ctx, task.ID); !errors.Is(err, domainErrors.NotFound) {
+		s.t.Errorf("GetTaskByID of a deleted column's task err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testCreateTask(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+
+	first := s.task(ctx, c.ID, "first")
+	second := s.task(ctx, c.ID, "second")
+	if first.ColumnID != c.ID || first.Description != "first" {
+		s.t.Fatalf("CreateTask = %+v", first)
+	}
+
+	found, err := s.Kanban.GetTaskByID(ctx, first.ID)
+	if err != nil {
+		s.t.Fatalf("GetTaskByID: %v", err)
+	}
+	if *found != *first {
+		s.t.Fatalf("GetTaskByID = %+v, want %+v", found, first)
+	}
+
+	tasks, err := s.Kanban.GetTasksByColumnID(ctx, c.ID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks, first, second)
+
+	if _, err = s.Kanban.CreateTask(ctx, &models.Task{ColumnID: -1, Description: "orphan"}); err == nil {
+		s.t.Fatal("CreateTask in a missing column succeeded")
+	}
+}
+
+func testChangeTask(ctx context.Context, s *suite) {
+	userID := s.user(ctx)
+	from := s.column(ctx, userID, "todo")
+	to := s.column(ctx, userID, "done")
+	task := s.task(ctx, from.ID, "task")
+
+	changed, err := s.Kanban.ChangeDescriptionTask(ctx, &models.Task{ID: task.ID, Description: "changed"})
+	if err != nil {
+		s.t.Fatalf("ChangeDescriptionTask: %v", err)
+	}
+	if *changed != (models.Task{ID: task.ID, ColumnID: from.ID, Description: "changed"}) {
+		s.t.Fatalf("ChangeDescriptionTask = %+v", changed)
+	}
+
+	moved, err := s.Kanban.ChangeColumnIDTask(ctx, &models.Task{ID: task.ID, ColumnID: to.ID})
+	if err != nil {
+		s.t.Fatalf("ChangeColumnIDTask: %v", err)
+	}
+	if *moved != (models.Task{ID: task.ID, ColumnID: to.ID, Description: "changed"}) {
+		s.t.Fatalf("ChangeColumnIDTask = %+v", moved)
+	}
+
+	tasks, err := s.Kanban.GetTasksByColumnID(ctx, from.ID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks)
+
+	tasks, err = s.Kanban.GetTasksByColumnID(ctx, to.ID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks, moved)
+}
+
+func testMoveTasks(ctx context.Context, s *suite) {
+	userID := s.user(ctx)
+	from := s.column(ctx, userID, "todo")
+	to := s.column(ctx, userID, "done")
+	first := s.task(ctx, from.ID, "first")
+	second := s.task(ctx, from.ID, "second")
+	kept := s.task(ctx, from.ID, "kept")
+
+	moved, err := s.Kanban.MoveTasks(ctx, []int{first.ID, second.ID, -1}, to.ID)
+	if err != nil {
+		s.t.Fatalf("MoveTasks: %v", err)
+	}
+	if len(moved) != 2 {
+		s.t.Fatalf("MoveTasks moved %d tasks, want 2", len(moved))
+	}
+
+	tasks, err := s.Kanban.GetTasksByColumnID(ctx, to.ID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks,
+		&models.Task{ID: first.ID, ColumnID: to.ID, Description: "first"},
+		&models.Task{ID: second.ID, ColumnID: to.ID, Description: "second"},
+	)
+
+	tasks, err = s.Kanban.GetTasksByColumnID(ctx, from.ID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks, kept)
+}
+
+func testDeleteTasks(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+	first := s.task(ctx, c.ID, "first")
+	kept := s.task(ctx, c.ID, "kept")
+
+	if err := s.Kanban.DeleteTasks(ctx, []int{first.ID, -1}); err != nil {
+		s.t.Fatalf("DeleteTasks: %v", err)
+	}
+
+	tasks, err := s.Kanban.GetTasksByColumnID(ctx, c.ID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks, kept)
+}
+
+func testCloneColumn(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+	first := s.task(ctx, c.ID, "first")
+	second := s.task(ctx, c.ID, "second")
+
+	clone, err := s.Kanban.CloneColumn(ctx, c.ID)
+	if err != nil {
+		s.t.Fatalf("CloneColumn: %v", err)
+	}
+
+	cloneID, ok := clone.Columns[c.ID]
+	if !ok || cloneID == c.ID {
+		s.t.Fatalf("CloneColumn columns = %v", clone.Columns)
+	}
+
+	cloned, err := s.Kanban.GetColumnByID(ctx, cloneID)
+	if err != nil {
+		s.t.Fatalf("GetColumnByID: %v", err)
+	}
+	if cloned.UserID != c.UserID || cloned.Name != c.Name {
+		s.t.Fatalf("cloned column = %+v, want a copy of %+v", cloned, c)
+	}
+
+	tasks, err := s.Kanban.GetTasksByColumnID(ctx, cloneID)
+	if err != nil {
+		s.t.Fatalf("GetTasksByColumnID: %v", err)
+	}
+	s.assertTasks(tasks,
+		&models.Task{ID: clone.Tasks[first.ID], ColumnID: cloneID, Description: "first"},
+		&models.Task{ID: clone.Tasks[second.ID], ColumnID: cloneID, Description: "second"},
+	)
+}
+
+func testRestore(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+	task := s.task(ctx, c.ID, "task")
+
+	if err := s.Kanban.DeleteColumn(ctx, c.ID); err != nil {
+		s.t.Fatalf("DeleteColumn: %v", err)
+	}
+
+	restored, err := s.Kanban.RestoreColumn(ctx, c)
+	if err != nil {
+		s.t.Fatalf("RestoreColumn: %v", err)
+	}
+	if *restored != *c {
+		s.t.Fatalf("RestoreColumn = %+v, want %+v", restored, c)
+	}
+
+	restoredTask, err := s.Kanban.RestoreTask(ctx, task)
+	if err != nil {
+		s.t.Fatalf("RestoreTask: %v", err)
+	}
+	if *restoredTask != *task {
+		s.t.Fatalf("RestoreTask = %+v, want %+v", restoredTask, task)
+	}
+
+	// restored IDs must never be handed out again
+	next := s.task(ctx, c.ID, "next")
+	if next.ID == task.ID {
+		s.t.Fatalf("CreateTask reused restored ID %d", task.ID)
+	}
+}
+
+func testBoard(ctx context.Context, s *suite) {
+	userID := s.user(ctx)
+	todo := s.column(ctx, userID, "todo")
+	empty := s.column(ctx, userID, "empty")
+	done := s.column(ctx, userID, "done")
+	first := s.task(ctx, todo.ID, "first")
+	second := s.task(ctx, todo.ID, "second")
+	third := s.task(ctx, done.ID, "third")
+
+	// columns of other users must not leak into the board
+	s.task(ctx, s.column(ctx, s.user(ctx), "foreign").ID, "foreign")
+
+	board, err := s.Kanban.GetKanbanBoardByUserID(ctx, userID)
+	if err != nil {
+		s.t.Fatalf("GetKanbanBoardByUserID: %v", err)
+	}
+
+	want := []*models.Col{
+		{ID: todo.ID, Name: "todo", Tasks: []*models.T{boardTask(first), boardTask(second)}},
+		{ID: empty.ID, Name: "empty", Tasks: []*models.T{}},
+		{ID: done.ID, Name: "done", Tasks: []*models.T{boardTask(third)}},
+	}
+
+	if len(board.Columns) != len(want) {
+		s.t.Fatalf("board has %d columns, want %d", len(board.Columns), len(want))
+	}
+	for i, col := range board.Columns {
+		if col.ID != want[i].ID || col.Name != want[i].Name {
+			s.t.Errorf("column %d = {%d %q}, want {%d %q}", i, col.ID, col.Name, want[i].ID, want[i].Name)
+		}
+		if col.Tasks == nil {
+			s.t.Errorf("column %d tasks are nil, want an empty slice", col.ID)
+		}
+		if len(col.Tasks) != len(want[i].Tasks) {
+			s.t.Errorf("column %d has %d tasks, want %d", col.ID, len(col.Tasks), len(want[i].Tasks))
+			continue
+		}
+		for j, task := range col.Tasks {
+			if *task != *want[i].Tasks[j] {
+				s.t.Errorf("column %d task %d = %+v, want %+v", col.ID, j, task, want[i].Tasks[j])
+			}
+		}
+	}
+}
+
+func testEmptyBoard(ctx context.Context, s *suite) {
+	board, err := s.Kanban.GetKanbanBoardByUserID(ctx, s.user(ctx))
+	if err != nil {
+		s.t.Fatalf("GetKanbanBoardByUserID: %v", err)
+	}
+	if board.Columns == nil || len(board.Columns) != 0 {
+		s.t.Fatalf("board columns = %v, want an empty slice", board.Columns)
+	}
+}
+
+func testBoardPage(ctx context.Context, s *suite) {
+	userID := s.user(ctx)
+	full := s.column(ctx, userID, "full")
+	empty := s.column(ctx, userID, "empty")
+	first := s.task(ctx, full.ID, "first")
+	second := s.task(ctx, full.ID, "second")
+	s.task(ctx, full.ID, "third")
+
+	board, err := s.Kanban.GetKanbanBoardPageByUserID(ctx, userID, 2)
+	if err != nil {
+		s.t.Fatalf("GetKanbanBoardPageByUserID: %v", err)
+	}
+
+	if len(board.Columns) != 2 || board.Columns[0].ID != full.ID || board.Columns[1].ID != empty.ID {
+		s.t.Fatalf("board columns = %v, want %d and %d", board.Columns, full.ID, empty.ID)
+	}
+	if tasks := board.Columns[0].Tasks; len(tasks) != 2 || *tasks[0] != *boardTask(first) || *tasks[1] != *boardTask(second) {
+		s.t.Errorf("first page = %v, want tasks %d and %d", tasks, first.ID, second.ID)
+	}
+	if tasks := board.Columns[1].Tasks; tasks == nil || len(tasks) != 0 {
+		s.t.Errorf("empty column tasks = %v, want an empty slice", tasks)
+	}
+}
+
+func testTasksPage(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+	first := s.task(ctx, c.ID, "first")
+	second := s.task(ctx, c.ID, "second")
+	third := s.task(ctx, c.ID, "third")
+
+	page, err := s.Kanban.GetTasksPageByColumnID(ctx, c.ID, 0, 2)
+	if err != nil {
+		s.t.Fatalf("GetTasksPageByColumnID: %v", err)
+	}
+	s.assertTasks(page, first, second)
+
+	page, err = s.Kanban.GetTasksPageByColumnID(ctx, c.ID, second.ID, 2)
+	if err != nil {
+		s.t.Fatalf("GetTasksPageByColumnID: %v", err)
+	}
+	s.assertTasks(page, third)
+
+	page, err = s.Kanban.GetTasksPageByColumnID(ctx, c.ID, third.ID, 2)
+	if err != nil {
+		s.t.Fatalf("GetTasksPageByColumnID: %v", err)
+	}
+	s.assertTasks(page)
+}
+
+func testRestoreConflict(ctx context.Context, s *suite) {
+	c := s.column(ctx, s.user(ctx), "todo")
+	task := s.task(ctx, c.ID, "task")
+
+	if _, err := s.Kanban.RestoreColumn(ctx, c); !errors.Is(err, domainErrors.Conflict) {
+		s.t.Errorf("RestoreColumn of an existing column err = %v, want domainErrors.Conflict", err)
+	}
+	if _, err := s.Kanban.RestoreTask(ctx, task); !errors.Is(err, domainErrors.Conflict) {
+		s.t.Errorf("RestoreTask of an existing task err = %v, want domainErrors.Conflict", err)
+	}
+}
+
+func testNotFound(ctx context.Context, s *suite) {
+	const missing = -1
+
+	checks := map[string]error{
+		"GetColumnByID":         second(s.Kanban.GetColumnByID(ctx, missing)),
+		"DeleteColumn":          s.Kanban.DeleteColumn(ctx, missing),
+		"ChangeNameColumn":      second(s.Kanban.ChangeNameColumn(ctx, &models.Column{ID: missing, Name: "name"})),
+		"GetTaskByID":           second(s.Kanban.GetTaskByID(ctx, missing)),
+		"DeleteTask":            s.Kanban.DeleteTask(ctx, missing),
+		"ChangeDescriptionTask": second(s.Kanban.ChangeDescriptionTask(ctx, &models.Task{ID: missing, Description: "description"})),
+		"CloneColumn":           second(s.Kanban.CloneColumn(ctx, missing)),
+		"CloneTask":             second(s.Kanban.CloneTask(ctx, missing)),
+	}
+
+	for name, err := range checks {
+		if !errors.Is(err, domainErrors.NotFound) {
+			s.t.Errorf("%s err = %v, want domainErrors.NotFound", name, err)
+		}
+	}
+}
+
+func (s *suite) user(ctx context.Context) int {
+	s.t.Helper()
+	u, err := s.Auth.Register(ctx, authStoragetest.NewUser())
+	if err != nil {
+		s.t.Fatalf("Register: %v", err)
+	}
+	return u.ID
+}
+
+func (s *suite) column(ctx context.Context, userID int, name string) *models.Column {
+	s.t.Helper()
+	c, err := s.Kanban.CreateColumn(ctx, &models.Column{UserID: userID, Name: name})
+	if err != nil {
+		s.t.Fatalf("CreateColumn: %v", err)
+	}
+	return c
+}
+
+func (s *suite) task(ctx context.Context, columnID int, description string) *models.Task {
+	s.t.Helper()
+	t, err := s.Kanban.CreateTask(ctx, &models.Task{ColumnID: columnID, Description: description})
+	if err != nil {
+		s.t.Fatalf("CreateTask: %v", err)
+	}
+	return t
+}
+
+// assertTasks compares tasks in order, so it also checks that storages sort tasks by ID.
+func (s *suite) assertTasks(got []*models.Task, want ...*models.Task) {
+	s.t.Helper()
+	if len(got) != len(want) {
+		s.t.Fatalf("got %d tasks, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if *got[i] != *want[i] {
+			s.t.Errorf("task %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func boardTask(t *models.Task) *models.T {
+	return &models.T{ID: t.ID, ColumnID: t.ColumnID, Description: t.Description}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+// BenchmarkBoard measures GetKanbanBoardByUserID on a board of columns x tasks.
+func BenchmarkBoard(b *testing.B, storages Storages, columns int, tasks int) {
+	ctx := context.Background()
+
+	u, err := storages.Auth.Register(ctx, authStoragetest.NewUser())
+	if err != nil {
+		b.Fatalf("Register: %v", err)
+	}
+
+	for i := 0; i < columns; i++ {
+		c, err := storages.Kanban.CreateColumn(ctx, &models.Column{UserID: u.ID, Name: fmt.Sprintf("column %d", i)})
+		if err != nil {
+			b.Fatalf("CreateColumn: %v", err)
+		}
+		for j := 0; j < tasks; j++ {
+			if _, err = storages.Kanban.CreateTask(ctx, &models.Task{ColumnID: c.ID, Description: fmt.Sprintf("task %d", j)}); err != nil {
+				b.Fatalf("CreateTask: %v", err)
+			}
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		board, err := storages.Kanban.GetKanbanBoardByUserID(ctx, u.ID)
+		if err != nil {
+			b.Fatalf("GetKanbanBoardByUserID: %v", err)
+		}
+		if len(board.Columns) != columns {
+			b.Fatalf("board has %d columns, want %d", len(board.Columns), columns)
+		}
+	}
+}
@@ -6,14 +6,26 @@ import (
 )
 
 type UseCase interface {
-	CreateColumn(ctx context.Context, column *models.Column) (*models.Column, error)
-	DeleteColumn(ctx context.Context, id int) error
-	ChangeNameColumn(ctx context.Context, column *models.Column) (*models.Column, error)
+	CreateColumn(ctx context.Context, userID int, column *models.Column) (*models.Column, error)
+	DeleteColumn(ctx context.Context, userID int, id int) error
+	PatchColumn(ctx context.Context, userID int, id int, patch func(column *models.Column) error) (*models.Column, error)
 
-	CreateTask(ctx context.Context, task *models.Task) (*models.Task, error)
-	DeleteTask(ctx context.Context, id int) error
-	ChangeDescriptionTask(ctx context.Context, task *models.Task) (*models.Task, error)
-	ChangeColumnIDTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	CreateTask(ctx context.Context, userID int, task *models.Task) (*models.Task, error)
+	DeleteTask(ctx context.Context, userID int, id int) error
+	PatchTask(ctx context.Context, userID int, id int, patch func(task *models.Task) error) (*models.Task, error)
+
+	CloneColumn(ctx context.Context, userID int, id int) (*models.Clone, error)
+	CloneTask(ctx context.Context, userID int, id int) (*models.Clone, error)
+
+	MoveTask(ctx context.Context, userID int, task *models.Task) (*models.Task, error)
+	CopyTask(ctx context.Context, userID int, task *models.Task) (*models.Task, error)
+	BulkTasks(ctx context.Context, userID int, bulk *models.BulkTasks) ([]*models.BulkTaskResult, error)
+	ExecuteBatch(ctx context.Context, userID int, batch *models.Batch) (*models.BatchResult, error)
+
+	Undo(ctx context.Context, userID int) (*models.HistoryAction, error)
+	Redo(ctx context.Context, userID int) (*models.HistoryAction, error)
 
 	GetKanbanBoardByUserID(ctx context.Context, userID int) (*models.Board, error)
+	GetKanbanBoardPageByUserID(ctx context.Context, userID int, limit int) (*models.Board, error)
+	GetTasksPageByColumnID(ctx context.Context, userID int, columnID int, cursor string, limit int) (*models.TaskPage, error)
 }
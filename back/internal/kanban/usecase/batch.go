@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+)
+
+func (kuc *kanbanUseCase) ExecuteBatch(ctx context.Context, userID int, batch *models.Batch) (*models.BatchResult, error) {
+	result := &models.BatchResult{
+		Refs:       make(map[string]int),
+		Operations: make([]*models.BatchOperationResult, 0, len(batch.Operations)),
+	}
+
+	var steps []*models.HistoryStep
+	refs := &batchRefs{ids: result.Refs, kinds: make(map[string]string)}
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, op := range batch.Operations {
+			opResult, opSteps, err := kuc.executeBatchOperation(ctx, userID, refs, op)
+			if err != nil {
+				return errors.Wrapf(err, "kanbanUseCase.ExecuteBatch: operation %d (%s)", i, op.Op)
+			}
+			result.Operations = append(result.Operations, opResult)
+			steps = append(steps, opSteps...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, steps...)
+
+	return result, nil
+}
+
+func (kuc *kanbanUseCase) executeBatchOperation(
+	ctx context.Context,
+	userID int,
+	refs *batchRefs,
+	op *models.BatchOperation,
+) (*models.BatchOperationResult, []*models.HistoryStep, error) {
+	if _, exists := refs.ids[op.Ref]; op.Ref != "" && exists {
+		return nil, nil, errors.Wrapf(domainErrors.Validation, "duplicate ref %s", op.Ref)
+	}
+
+	result := &models.BatchOperationResult{Op: op.Op}
+	var steps []*models.HistoryStep
+
+	switch op.Op {
+	case models.BatchCreateColumn:
+		column, err := kuc.kanbanStorage.CreateColumn(ctx, &models.Column{UserID: userID, Name: op.Name})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Column = column
+		steps = append(steps, &models.HistoryStep{ColumnAfter: column})
+	case models.BatchChangeNameColumn:
+		id, err := refs.resolve(batchRefColumn, op.ID, op.IDRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		oldColumn, err := kuc.checkColumnOwner(ctx, userID, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		column, err := kuc.kanbanStorage.ChangeNameColumn(ctx, &models.Column{ID: id, Name: op.Name})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Column = column
+		steps = append(steps, &models.HistoryStep{ColumnBefore: oldColumn, ColumnAfter: column})
+	case models.BatchDeleteColumn:
+		id, err := refs.resolve(batchRefColumn, op.ID, op.IDRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		column, err := kuc.checkColumnOwner(ctx, userID, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks, err := kuc.kanbanStorage.GetTasksByColumnID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = kuc.kanbanStorage.DeleteColumn(ctx, id); err != nil {
+			return nil, nil, err
+		}
+		steps = deleteColumnSteps(column, tasks)
+	case models.BatchCreateTask:
+		columnID, err := refs.resolve(batchRefColumn, op.ColumnID, op.ColumnRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err = kuc.checkColumnOwner(ctx, userID, columnID); err != nil {
+			return nil, nil, err
+		}
+		task, err := kuc.kanbanStorage.CreateTask(ctx, &models.Task{ColumnID: columnID, Description: op.Description})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Task = task
+		steps = append(steps, &models.HistoryStep{TaskAfter: task})
+	case models.BatchChangeDescriptionTask:
+		id, err := refs.resolve(batchRefTask, op.ID, op.IDRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		oldTask, err := kuc.checkTaskOwner(ctx, userID, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		task, err := kuc.kanbanStorage.ChangeDescriptionTask(ctx, &models.Task{ID: id, Description: op.Description})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Task = task
+		steps = append(steps, &models.HistoryStep{TaskBefore: oldTask, TaskAfter: task})
+	case models.BatchMoveTask:
+		id, err := refs.resolve(batchRefTask, op.ID, op.IDRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		columnID, err := refs.resolve(batchRefColumn, op.ColumnID, op.ColumnRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		oldTask, err := kuc.checkTaskOwner(ctx, userID, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err = kuc.checkColumnOwner(ctx, userID, columnID); err != nil {
+			return nil, nil, err
+		}
+		task, err := kuc.kanbanStorage.ChangeColumnIDTask(ctx, &models.Task{ID: id, ColumnID: columnID})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Task = task
+		steps = append(steps, &models.HistoryStep{TaskBefore: oldTask, TaskAfter: task})
+	case models.BatchDeleteTask:
+		id, err := refs.resolve(batchRefTask, op.ID, op.IDRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		task, err := kuc.checkTaskOwner(ctx, userID, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = kuc.kanbanStorage.DeleteTask(ctx, id); err != nil {
+			return nil, nil, err
+		}
+		steps = append(steps, &models.HistoryStep{TaskBefore: task})
+	default:
+		return nil, nil, errors.Wrapf(domainErrors.Validation, "unknown operation %s", op.Op)
+	}
+
+	if op.Ref != "" {
+		switch {
+		case result.Task != nil:
+			refs.ids[op.Ref], refs.kinds[op.Ref] = result.Task.ID, batchRefTask
+		case result.Column != nil:
+			refs.ids[op.Ref], refs.kinds[op.Ref] = result.Column.ID, batchRefColumn
+		}
+	}
+
+	return result, steps, nil
+}
+
+// Kinds of the entities batch refs point to.
+const (
+	batchRefColumn = "column"
+	batchRefTask   = "task"
+)
+
+// batchRefs keeps the IDs published by earlier operations of a batch together with
+// the kind of entity they point to.
+type batchRefs struct {
+	ids   map[string]int
+	kinds map[string]string
+}
+
+// resolve returns the ID published under ref, or id when no ref is given. A ref to
+// another kind of entity is rejected, so a task is never taken for a column.
+func (r *batchRefs) resolve(kind string, id int, ref string) (int, error) {
+	if ref == "" {
+		if id == 0 {
+			return 0, errors.Wrap(domainErrors.Validation, "missing id")
+		}
+		return id, nil
+	}
+
+	resolved, ok := r.ids[ref]
+	if !ok {
+		return 0, errors.Wrapf(domainErrors.Validation, "unknown ref %s", ref)
+	}
+	if r.kinds[ref] != kind {
+		return 0, errors.Wrapf(domainErrors.Validation, "ref %s is a %s, not a %s", ref, r.kinds[ref], kind)
+	}
+
+	return resolved, nil
+}
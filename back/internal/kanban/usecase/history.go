@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+)
+
+func (kuc *kanbanUseCase) Undo(ctx context.Context, userID int) (*models.HistoryAction, error) {
+	return kuc.replay(ctx, userID, models.HistoryUndo, models.HistoryRedo)
+}
+
+func (kuc *kanbanUseCase) Redo(ctx context.Context, userID int) (*models.HistoryAction, error) {
+	return kuc.replay(ctx, userID, models.HistoryRedo, models.HistoryUndo)
+}
+
+// replay takes the latest action from one stack, applies it to the board in a single
+// transaction and moves it onto the other stack.
+func (kuc *kanbanUseCase) replay(ctx context.Context, userID int, from string, to string) (*models.HistoryAction, error) {
+	action, err := kuc.historyStorage.Pop(ctx, userID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	err = kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if from == models.HistoryUndo {
+			for i := len(action.Steps) - 1; i >= 0; i-- {
+				if err := applyHistoryStep(ctx, kuc.kanbanStorage, action.Steps[i], true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, step := range action.Steps {
+			if err := applyHistoryStep(ctx, kuc.kanbanStorage, step, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// an action that conflicts with the current board can never be applied again
+		if !errors.Is(err, domainErrors.Conflict) {
+			if pushErr := kuc.historyStorage.Push(ctx, userID, from, action); pushErr != nil {
+				kuc.log.WarnMsg("kanbanUseCase.replay.Push", pushErr)
+			}
+		}
+		return nil, err
+	}
+
+	kuc.invalidate(ctx, userID)
+
+	if err = kuc.historyStorage.Push(ctx, userID, to, action); err != nil {
+		kuc.log.WarnMsg("kanbanUseCase.replay.Push", err)
+	}
+
+	return action, nil
+}
+
+// record puts a new action onto the user's undo stack. A new action invalidates
+// everything that could be redone. History failures never fail the mutation itself.
+func (kuc *kanbanUseCase) record(ctx context.Context, userID int, steps ...*models.HistoryStep) {
+	if len(steps) == 0 {
+		return
+	}
+
+	if err := kuc.historyStorage.Push(ctx, userID, models.HistoryUndo, &models.HistoryAction{Steps: steps}); err != nil {
+		kuc.log.WarnMsg("kanbanUseCase.record.Push", err)
+		return
+	}
+
+	if err := kuc.historyStorage.Clear(ctx, userID, models.HistoryRedo); err != nil {
+		kuc.log.WarnMsg("kanbanUseCase.record.Clear", err)
+	}
+}
+
+func createColumnSteps(column *models.Column, tasks []*models.Task) []*models.HistoryStep {
+	steps := make([]*models.HistoryStep, 0, len(tasks)+1)
+	steps = append(steps, &models.HistoryStep{ColumnAfter: column})
+	for _, task := range tasks {
+		steps = append(steps, &models.HistoryStep{TaskAfter: task})
+	}
+	return steps
+}
+
+func deleteColumnSteps(column *models.Column, tasks []*models.Task) []*models.HistoryStep {
+	steps := make([]*models.HistoryStep, 0, len(tasks)+1)
+	for _, task := range tasks {
+		steps = append(steps, &models.HistoryStep{TaskBefore: task})
+	}
+	steps = append(steps, &models.HistoryStep{ColumnBefore: column})
+	return steps
+}
+
+func applyHistoryStep(ctx context.Context, storage kanban.Storage, step *models.HistoryStep, reverse bool) error {
+	if step.ColumnBefore != nil || step.ColumnAfter != nil {
+		from, to := step.ColumnBefore, step.ColumnAfter
+		if reverse {
+			from, to = to, from
+		}
+		return transitColumn(ctx, storage, from, to)
+	}
+
+	from, to := step.TaskBefore, step.TaskAfter
+	if reverse {
+		from, to = to, from
+	}
+	return transitTask(ctx, storage, from, to)
+}
+
+// transitColumn moves a column from one recorded state to another, failing with
+// domainErrors.Conflict when the column is no longer in the expected state.
+func transitColumn(ctx context.Context, storage kanban.Storage, from *models.Column, to *models.Column) error {
+	id := columnID(from, to)
+
+	current, err := storage.GetColumnByID(ctx, id)
+	if err != nil && !errors.Is(err, domainErrors.NotFound) {
+		return err
+	}
+
+	if !sameColumn(current, from) {
+		return errors.Wrapf(domainErrors.Conflict, "column %d has been changed", id)
+	}
+
+	switch {
+	case to == nil:
+		tasks, err := storage.GetTasksByColumnID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(tasks) > 0 {
+			return errors.Wrapf(domainErrors.Conflict, "column %d has new tasks", id)
+		}
+		return storage.DeleteColumn(ctx, id)
+	case from == nil:
+		_, err = storage.RestoreColumn(ctx, to)
+	default:
+		_, err = storage.ChangeNameColumn(ctx, to)
+	}
+
+	return err
+}
+
+// transitTask moves a task from one recorded state to another, failing with
+// domainErrors.Conflict when the task is no longer in the expected state.
+func transitTask(ctx context.Context, storage kanban.Storage, from *models.Task, to *models.Task) error {
+	id := taskID(from, to)
+
+	current, err := storage.GetTaskByID(ctx, id)
+	if err != nil && !errors.Is(err, domainErrors.NotFound) {
+		return err
+	}
+
+	if !sameTask(current, from) {
+		return errors.Wrapf(domainErrors.Conflict, "task %d has been changed", id)
+	}
+
+	if to != nil && (from == nil || from.ColumnID != to.ColumnID) {
+		if _, err = storage.GetColumnByID(ctx, to.ColumnID); err != nil {
+			if errors.Is(err, domainErrors.NotFound) {
+				return errors.Wrapf(domainErrors.Conflict, "column %d of task %d no longer exists", to.ColumnID, id)
+			}
+			return err
+		}
+	}
+
+	switch {
+	case to == nil:
+		return storage.DeleteTask(ctx, id)
+	case from == nil:
+		_, err = storage.RestoreTask(ctx, to)
+		return err
+	}
+
+	if from.Description != to.Description {
+		if _, err = storage.ChangeDescriptionTask(ctx, to); err != nil {
+			return err
+		}
+	}
+
+	if from.ColumnID != to.ColumnID {
+		if _, err = storage.ChangeColumnIDTask(ctx, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func columnID(from *models.Column, to *models.Column) int {
+	if from != nil {
+		return from.ID
+	}
+	return to.ID
+}
+
+func taskID(from *models.Task, to *models.Task) int {
+	if from != nil {
+		return from.ID
+	}
+	return to.ID
+}
+
+func sameColumn(a *models.Column, b *models.Column) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTask(a *models.Task, b *models.Task) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
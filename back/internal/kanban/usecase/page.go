@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+)
+
+// GetKanbanBoardPageByUserID returns the first page of tasks of every column. Paginated
+// boards are read from the storage directly and never cached.
+func (kuc *kanbanUseCase) GetKanbanBoardPageByUserID(ctx context.Context, userID int, limit int) (*models.Board, error) {
+	limit = pageSize(limit)
+
+	// one extra task tells whether a column has another page
+	board, err := kuc.kanbanStorage.GetKanbanBoardPageByUserID(ctx, userID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range board.Columns {
+		if len(col.Tasks) > limit {
+			col.Tasks = col.Tasks[:limit]
+			col.NextCursor = models.EncodeCursor(col.Tasks[limit-1].ID)
+		}
+	}
+
+	return board, nil
+}
+
+// GetTasksPageByColumnID returns the tasks after the cursor of a column the user owns.
+func (kuc *kanbanUseCase) GetTasksPageByColumnID(ctx context.Context, userID int, columnID int, cursor string, limit int) (*models.TaskPage, error) {
+	afterID, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return nil, domainErrors.NewValidationError(domainErrors.FieldError{Field: "cursor", Rule: "cursor"})
+	}
+
+	if _, err = kuc.checkColumnOwner(ctx, userID, columnID); err != nil {
+		return nil, err
+	}
+
+	limit = pageSize(limit)
+
+	tasks, err := kuc.kanbanStorage.GetTasksPageByColumnID(ctx, columnID, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TaskPage{Tasks: make([]*models.T, 0, len(tasks))}
+	for _, task := range tasks {
+		page.Tasks = append(page.Tasks, &models.T{ID: task.ID, ColumnID: task.ColumnID, Description: task.Description})
+	}
+
+	if len(page.Tasks) > limit {
+		page.Tasks = page.Tasks[:limit]
+		page.NextCursor = models.EncodeCursor(page.Tasks[limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// pageSize falls back to the default for a missing limit and caps it at the maximum.
+func pageSize(limit int) int {
+	switch {
+	case limit <= 0:
+		return models.DefaultPageSize
+	case limit > models.MaxPageSize:
+		return models.MaxPageSize
+	}
+	return limit
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+)
+
+// PatchColumn applies patch to a copy of the user's column and stores the changed
+// fields. Only the name of a column can be changed.
+func (kuc *kanbanUseCase) PatchColumn(ctx context.Context, userID int, id int, patch func(column *models.Column) error) (*models.Column, error) {
+	var oldColumn, updatedColumn *models.Column
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if oldColumn, err = kuc.checkColumnOwner(ctx, userID, id); err != nil {
+			return err
+		}
+
+		column := *oldColumn
+		if err = patch(&column); err != nil {
+			return err
+		}
+
+		var immutable []domainErrors.FieldError
+		if column.ID != oldColumn.ID {
+			immutable = append(immutable, domainErrors.FieldError{Field: "id", Rule: "immutable"})
+		}
+		if column.UserID != oldColumn.UserID {
+			immutable = append(immutable, domainErrors.FieldError{Field: "user_id", Rule: "immutable"})
+		}
+		if len(immutable) > 0 {
+			return domainErrors.NewValidationError(immutable...)
+		}
+
+		updatedColumn = oldColumn
+		if column.Name != oldColumn.Name {
+			updatedColumn, err = kuc.kanbanStorage.ChangeNameColumn(ctx, &column)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if *updatedColumn != *oldColumn {
+		kuc.invalidate(ctx, userID)
+		kuc.record(ctx, userID, &models.HistoryStep{ColumnBefore: oldColumn, ColumnAfter: updatedColumn})
+	}
+
+	return updatedColumn, nil
+}
+
+// PatchTask applies patch to a copy of the user's task and stores the changed fields
+// in a single transaction. A new column_id is checked like a move.
+func (kuc *kanbanUseCase) PatchTask(ctx context.Context, userID int, id int, patch func(task *models.Task) error) (*models.Task, error) {
+	var oldTask, updatedTask *models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if oldTask, err = kuc.checkTaskOwner(ctx, userID, id); err != nil {
+			return err
+		}
+
+		task := *oldTask
+		if err = patch(&task); err != nil {
+			return err
+		}
+
+		if task.ID != oldTask.ID {
+			return domainErrors.NewValidationError(domainErrors.FieldError{Field: "id", Rule: "immutable"})
+		}
+
+		if task.ColumnID != oldTask.ColumnID {
+			if _, err = kuc.checkTaskTransfer(ctx, userID, &task); err != nil {
+				return err
+			}
+		}
+
+		updatedTask = oldTask
+		if task.Description != oldTask.Description {
+			if updatedTask, err = kuc.kanbanStorage.ChangeDescriptionTask(ctx, &task); err != nil {
+				return err
+			}
+		}
+		if task.ColumnID != oldTask.ColumnID {
+			if updatedTask, err = kuc.kanbanStorage.ChangeColumnIDTask(ctx, &task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if *updatedTask == *oldTask {
+		return updatedTask, nil
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, &models.HistoryStep{TaskBefore: oldTask, TaskAfter: updatedTask})
+
+	return updatedTask, nil
+}
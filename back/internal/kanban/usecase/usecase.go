@@ -2,80 +2,429 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/kanban"
 	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+	"time"
 )
 
+// boardLoadTimeout bounds a board read shared by concurrent requests. The read does
+// not use the context of the request that started it, so cancelling that request does
+// not fail the others.
+const boardLoadTimeout = 15 * time.Second
+
 type kanbanUseCase struct {
-	cfg           *config.Config
-	kanbanStorage kanban.Storage
-	log           logger.Logger
+	cfg            *config.Config
+	txManager      transaction.Manager
+	kanbanStorage  kanban.Storage
+	historyStorage kanban.HistoryStorage
+	boardCache     kanban.BoardCache
+	boards         singleflight.Group
+	log            logger.Logger
 }
 
-func NewKanbanUseCase(cfg *config.Config, kanbanStorage kanban.Storage, log logger.Logger) kanban.UseCase {
-	return &kanbanUseCase{cfg: cfg, kanbanStorage: kanbanStorage, log: log}
+func NewKanbanUseCase(
+	cfg *config.Config,
+	txManager transaction.Manager,
+	kanbanStorage kanban.Storage,
+	historyStorage kanban.HistoryStorage,
+	boardCache kanban.BoardCache,
+	log logger.Logger,
+) kanban.UseCase {
+	return &kanbanUseCase{cfg: cfg, txManager: txManager, kanbanStorage: kanbanStorage, historyStorage: historyStorage, boardCache: boardCache, log: log}
 }
 
-func (kuc *kanbanUseCase) CreateColumn(ctx context.Context, column *models.Column) (*models.Column, error) {
+func (kuc *kanbanUseCase) CreateColumn(ctx context.Context, userID int, column *models.Column) (*models.Column, error) {
+	column.UserID = userID
+
 	createdColumn, err := kuc.kanbanStorage.CreateColumn(ctx, column)
 	if err != nil {
 		return nil, err
 	}
 
+	kuc.invalidate(ctx, createdColumn.UserID)
+	kuc.record(ctx, createdColumn.UserID, &models.HistoryStep{ColumnAfter: createdColumn})
+
 	return createdColumn, nil
 }
 
-func (kuc *kanbanUseCase) DeleteColumn(ctx context.Context, id int) error {
-	return kuc.kanbanStorage.DeleteColumn(ctx, id)
+func (kuc *kanbanUseCase) DeleteColumn(ctx context.Context, userID int, id int) error {
+	var column *models.Column
+	var tasks []*models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if column, err = kuc.checkColumnOwner(ctx, userID, id); err != nil {
+			return err
+		}
+		if tasks, err = kuc.kanbanStorage.GetTasksByColumnID(ctx, id); err != nil {
+			return err
+		}
+		return kuc.kanbanStorage.DeleteColumn(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, deleteColumnSteps(column, tasks)...)
+
+	return nil
 }
 
-func (kuc *kanbanUseCase) ChangeNameColumn(ctx context.Context, column *models.Column) (*models.Column, error) {
-	updatedColumn, err := kuc.kanbanStorage.ChangeNameColumn(ctx, column)
+func (kuc *kanbanUseCase) CreateTask(ctx context.Context, userID int, task *models.Task) (*models.Task, error) {
+	var createdTask *models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := kuc.checkColumnOwner(ctx, userID, task.ColumnID); err != nil {
+			return err
+		}
+
+		var err error
+		createdTask, err = kuc.kanbanStorage.CreateTask(ctx, task)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return updatedColumn, nil
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, &models.HistoryStep{TaskAfter: createdTask})
+
+	return createdTask, nil
+}
+
+func (kuc *kanbanUseCase) DeleteTask(ctx context.Context, userID int, id int) error {
+	var task *models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if task, err = kuc.checkTaskOwner(ctx, userID, id); err != nil {
+			return err
+		}
+		return kuc.kanbanStorage.DeleteTask(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, &models.HistoryStep{TaskBefore: task})
+
+	return nil
 }
 
-func (kuc *kanbanUseCase) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
-	createdTask, err := kuc.kanbanStorage.CreateTask(ctx, task)
+func (kuc *kanbanUseCase) CloneColumn(ctx context.Context, userID int, id int) (*models.Clone, error) {
+	var clone *models.Clone
+	var column *models.Column
+	var tasks []*models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := kuc.checkColumnOwner(ctx, userID, id); err != nil {
+			return err
+		}
+
+		var err error
+		if clone, err = kuc.kanbanStorage.CloneColumn(ctx, id); err != nil {
+			return err
+		}
+		if column, err = kuc.kanbanStorage.GetColumnByID(ctx, clone.Columns[id]); err != nil {
+			return err
+		}
+		tasks, err = kuc.kanbanStorage.GetTasksByColumnID(ctx, column.ID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return createdTask, nil
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, createColumnSteps(column, tasks)...)
+
+	return clone, nil
+}
+
+func (kuc *kanbanUseCase) CloneTask(ctx context.Context, userID int, id int) (*models.Clone, error) {
+	var clone *models.Clone
+	var task *models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := kuc.checkTaskOwner(ctx, userID, id); err != nil {
+			return err
+		}
+
+		var err error
+		if clone, err = kuc.kanbanStorage.CloneTask(ctx, id); err != nil {
+			return err
+		}
+		task, err = kuc.kanbanStorage.GetTaskByID(ctx, clone.Tasks[id])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, &models.HistoryStep{TaskAfter: task})
+
+	return clone, nil
+}
+
+// MoveTask moves the task to another column. Boards are not shared in this tree, so
+// both columns must be on the caller's board and the history is recorded there.
+func (kuc *kanbanUseCase) MoveTask(ctx context.Context, userID int, task *models.Task) (*models.Task, error) {
+	var oldTask, movedTask *models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if oldTask, err = kuc.checkTaskTransfer(ctx, userID, task); err != nil {
+			return err
+		}
+		movedTask, err = kuc.kanbanStorage.ChangeColumnIDTask(ctx, task)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, &models.HistoryStep{TaskBefore: oldTask, TaskAfter: movedTask})
+
+	return movedTask, nil
+}
+
+// CopyTask copies the task into another column of the caller's board, like MoveTask.
+func (kuc *kanbanUseCase) CopyTask(ctx context.Context, userID int, task *models.Task) (*models.Task, error) {
+	var copiedTask *models.Task
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := kuc.checkTaskTransfer(ctx, userID, task); err != nil {
+			return err
+		}
+
+		var err error
+		copiedTask, err = kuc.kanbanStorage.CopyTask(ctx, task)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, &models.HistoryStep{TaskAfter: copiedTask})
+
+	return copiedTask, nil
+}
+
+// BulkTasks applies one operation to many tasks in a single transaction. Missing tasks
+// are reported per item, while a task or column on somebody else's board fails the whole request.
+func (kuc *kanbanUseCase) BulkTasks(ctx context.Context, userID int, bulk *models.BulkTasks) ([]*models.BulkTaskResult, error) {
+	var results []*models.BulkTaskResult
+	var steps []*models.HistoryStep
+
+	err := kuc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		results, steps, err = kuc.applyBulkTasks(ctx, userID, bulk)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kuc.invalidate(ctx, userID)
+	kuc.record(ctx, userID, steps...)
+
+	return results, nil
 }
 
-func (kuc *kanbanUseCase) DeleteTask(ctx context.Context, id int) error {
-	return kuc.kanbanStorage.DeleteTask(ctx, id)
+func (kuc *kanbanUseCase) applyBulkTasks(ctx context.Context, userID int, bulk *models.BulkTasks) ([]*models.BulkTaskResult, []*models.HistoryStep, error) {
+	owners := make(map[int]int)
+	checkColumn := func(columnID int) error {
+		ownerID, ok := owners[columnID]
+		if !ok {
+			column, err := kuc.kanbanStorage.GetColumnByID(ctx, columnID)
+			if err != nil {
+				return err
+			}
+			ownerID = column.UserID
+			owners[columnID] = ownerID
+		}
+		if ownerID != userID {
+			return errors.Wrapf(domainErrors.Forbidden, "kanbanUseCase.applyBulkTasks: user %d, column %d", userID, columnID)
+		}
+		return nil
+	}
+
+	if bulk.Operation == models.BulkOperationMove {
+		if err := checkColumn(bulk.ColumnID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	seen := make(map[int]bool, len(bulk.TaskIDs))
+	for _, id := range bulk.TaskIDs {
+		if seen[id] {
+			return nil, nil, domainErrors.NewValidationError(domainErrors.FieldError{Field: "task_ids", Rule: "unique"})
+		}
+		seen[id] = true
+	}
+
+	results := make([]*models.BulkTaskResult, 0, len(bulk.TaskIDs))
+	resultsByID := make(map[int]*models.BulkTaskResult, len(bulk.TaskIDs))
+	tasksByID := make(map[int]*models.Task, len(bulk.TaskIDs))
+	ids := make([]int, 0, len(bulk.TaskIDs))
+
+	for _, id := range bulk.TaskIDs {
+		result := &models.BulkTaskResult{TaskID: id, Status: models.BulkStatusOK}
+		results = append(results, result)
+
+		task, err := kuc.kanbanStorage.GetTaskByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, domainErrors.NotFound) {
+				result.Status = models.BulkStatusNotFound
+				continue
+			}
+			return nil, nil, err
+		}
+
+		if err = checkColumn(task.ColumnID); err != nil {
+			return nil, nil, err
+		}
+
+		resultsByID[id] = result
+		tasksByID[id] = task
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return results, nil, nil
+	}
+
+	steps := make([]*models.HistoryStep, 0, len(ids))
+
+	switch bulk.Operation {
+	case models.BulkOperationMove:
+		tasks, err := kuc.kanbanStorage.MoveTasks(ctx, ids, bulk.ColumnID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, task := range tasks {
+			resultsByID[task.ID].Task = task
+			steps = append(steps, &models.HistoryStep{TaskBefore: tasksByID[task.ID], TaskAfter: task})
+		}
+	case models.BulkOperationDelete:
+		if err := kuc.kanbanStorage.DeleteTasks(ctx, ids); err != nil {
+			return nil, nil, err
+		}
+		for _, id := range ids {
+			steps = append(steps, &models.HistoryStep{TaskBefore: tasksByID[id]})
+		}
+	default:
+		return nil, nil, errors.Wrapf(domainErrors.Validation, "kanbanUseCase.applyBulkTasks: operation %s", bulk.Operation)
+	}
+
+	return results, steps, nil
 }
 
-func (kuc *kanbanUseCase) ChangeDescriptionTask(ctx context.Context, task *models.Task) (*models.Task, error) {
-	updatedTask, err := kuc.kanbanStorage.ChangeDescriptionTask(ctx, task)
+// checkTaskTransfer makes sure the user owns both the board the task is on and the
+// board of the column it is transferred to, and returns the task. A user can only
+// edit their own board, so a transfer never leaves it.
+func (kuc *kanbanUseCase) checkTaskTransfer(ctx context.Context, userID int, task *models.Task) (*models.Task, error) {
+	source, err := kuc.checkTaskOwner(ctx, userID, task.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return updatedTask, nil
+	if _, err = kuc.checkColumnOwner(ctx, userID, task.ColumnID); err != nil {
+		return nil, err
+	}
+
+	return source, nil
 }
 
-func (kuc *kanbanUseCase) ChangeColumnIDTask(ctx context.Context, task *models.Task) (*models.Task, error) {
-	updatedTask, err := kuc.kanbanStorage.ChangeColumnIDTask(ctx, task)
+func (kuc *kanbanUseCase) checkTaskOwner(ctx context.Context, userID int, taskID int) (*models.Task, error) {
+	task, err := kuc.kanbanStorage.GetTaskByID(ctx, taskID)
 	if err != nil {
 		return nil, err
 	}
 
-	return updatedTask, nil
+	if _, err = kuc.checkColumnOwner(ctx, userID, task.ColumnID); err != nil {
+		return nil, err
+	}
+
+	return task, nil
 }
 
+func (kuc *kanbanUseCase) checkColumnOwner(ctx context.Context, userID int, columnID int) (*models.Column, error) {
+	column, err := kuc.kanbanStorage.GetColumnByID(ctx, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if column.UserID != userID {
+		return nil, errors.Wrapf(domainErrors.Forbidden, "user %d, column %d", userID, columnID)
+	}
+
+	return column, nil
+}
+
+// GetKanbanBoardByUserID serves the board from the cache when it is enabled. Concurrent
+// misses for the same board version share a single storage read, which runs with a
+// context of its own.
 func (kuc *kanbanUseCase) GetKanbanBoardByUserID(ctx context.Context, userID int) (*models.Board, error) {
-	board, err := kuc.kanbanStorage.GetKanbanBoardByUserID(ctx, userID)
+	if kuc.boardCache == nil {
+		return kuc.kanbanStorage.GetKanbanBoardByUserID(ctx, userID)
+	}
+
+	version, err := kuc.boardCache.Version(ctx, userID)
+	if err != nil {
+		kuc.log.WarnMsg("kanbanUseCase.GetKanbanBoardByUserID.Version", err)
+		return kuc.kanbanStorage.GetKanbanBoardByUserID(ctx, userID)
+	}
+
+	board, err, _ := kuc.boards.Do(fmt.Sprintf("%d:%d", userID, version), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), boardLoadTimeout)
+		defer cancel()
+
+		board, err := kuc.boardCache.GetBoard(ctx, userID, version)
+		if err != nil {
+			kuc.log.WarnMsg("kanbanUseCase.GetKanbanBoardByUserID.GetBoard", err)
+		}
+		if board != nil {
+			return board, nil
+		}
+
+		if board, err = kuc.kanbanStorage.GetKanbanBoardByUserID(ctx, userID); err != nil {
+			return nil, err
+		}
+
+		if err = kuc.boardCache.SetBoard(ctx, userID, version, board); err != nil {
+			kuc.log.WarnMsg("kanbanUseCase.GetKanbanBoardByUserID.SetBoard", err)
+		}
+
+		return board, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return board, nil
+	return board.(*models.Board), nil
+}
+
+// invalidate drops the cached board of the user. Every write calls it on its own, so
+// the cache stays correct even when recording the history fails.
+func (kuc *kanbanUseCase) invalidate(ctx context.Context, userID int) {
+	if kuc.boardCache == nil {
+		return
+	}
+
+	if err := kuc.boardCache.Invalidate(ctx, userID); err != nil {
+		kuc.log.WarnMsg("kanbanUseCase.invalidate", err)
+	}
 }
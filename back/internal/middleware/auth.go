@@ -2,10 +2,11 @@ package middleware
 
 import (
 	"context"
-	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
 	"github.com/aakosarev/kanban-board/back/pkg/utils"
 	"github.com/labstack/echo/v4"
-	"net/http"
+	"github.com/pkg/errors"
 	"strconv"
 )
 
@@ -17,10 +18,7 @@ func (m *Manager) AuthSessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 				utils.GetRequestID(c),
 				err.Error(),
 			)
-			if err == http.ErrNoCookie {
-				return httpErrors.NewUnauthorizedError(c, err, m.cfg.Http.DebugErrorsResponse)
-			}
-			return httpErrors.NewUnauthorizedError(c, httpErrors.Unauthorized, m.cfg.Http.DebugErrorsResponse)
+			return errors.Wrap(domainErrors.Unauthorized, err.Error())
 		}
 
 		sid := cookie.Value
@@ -32,7 +30,11 @@ func (m *Manager) AuthSessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 				cookie.Value,
 				err.Error(),
 			)
-			return httpErrors.NewUnauthorizedError(c, httpErrors.Unauthorized, m.cfg.Http.DebugErrorsResponse)
+			return domainErrors.Unauthorized
+		}
+
+		if err = m.sessionUseCase.Touch(c.Request().Context(), cookie.Value, sess, utils.GetIPAddress(c)); err != nil {
+			m.logger.WarnMsg("AuthSessionMiddleware.Touch", err)
 		}
 
 		user, err := m.authUseCase.GetByID(c.Request().Context(), sess.UserID)
@@ -41,7 +43,7 @@ func (m *Manager) AuthSessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 				utils.GetRequestID(c),
 				err.Error(),
 			)
-			return httpErrors.NewUnauthorizedError(c, httpErrors.Unauthorized, m.cfg.Http.DebugErrorsResponse)
+			return domainErrors.Unauthorized
 		}
 
 		c.Set("sid", sid)
@@ -49,8 +51,13 @@ func (m *Manager) AuthSessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 		c.Set("user", user)
 
 		ctx := context.WithValue(c.Request().Context(), utils.UserCtxKey{}, user)
+		ctx = context.WithValue(ctx, utils.SessionCtxKey{}, sess)
 		c.SetRequest(c.Request().WithContext(ctx))
 
+		if i18n.Supported(user.Locale) {
+			setLocale(c, user.Locale)
+		}
+
 		m.logger.Info(
 			"SessionMiddleware, RequestID: %s,  IP: %s, UserID: %s, CookieSessionID: %s",
 			utils.GetRequestID(c),
@@ -62,3 +69,24 @@ func (m *Manager) AuthSessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 		return next(c)
 	}
 }
+
+// VerifiedEmailMiddleware rejects users with an unverified email when verification
+// is required. It must run after AuthSessionMiddleware.
+func (m *Manager) VerifiedEmailMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !m.cfg.Verification.Required {
+			return next(c)
+		}
+
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		if !user.EmailVerified {
+			return errors.Wrap(domainErrors.Forbidden, "email is not verified")
+		}
+
+		return next(c)
+	}
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key header safe to
+// retry. The first response for a key is stored and replayed for retries with the same
+// body; reusing the key with a different body fails with 422 and a retry that arrives
+// while the first request is still running fails with 409. Transient failures and
+// responses that set cookies are not stored, so such requests run again on a retry with
+// the same key. It goes after AuthSessionMiddleware on a route, so keys are scoped to
+// the signed-in user, and requests without one are passed through, as anonymous clients
+// would otherwise share a single key space.
+func (m *Manager) IdempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(constants.IdempotencyKey)
+		if c.Request().Method != http.MethodPost || key == "" {
+			return next(c)
+		}
+
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			return next(c)
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return errors.Wrapf(httpErrors.BadRequest, "%s is longer than %d characters", constants.IdempotencyKey, maxIdempotencyKeyLength)
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return errors.Wrap(httpErrors.BadRequest, err.Error())
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request().Context()
+		key = idempotencyScope(c, user.ID, key)
+		fingerprint := hash(body)
+
+		locked, err := m.idempotencyStorage.Lock(ctx, key, &models.IdempotentResponse{Fingerprint: fingerprint}, m.cfg.Idempotency.Expire)
+		if err != nil {
+			m.logger.Errorf("IdempotencyMiddleware RequestID: %s, Error: %s", utils.GetRequestID(c), err.Error())
+			return err
+		}
+
+		if !locked {
+			return m.replay(c, key, fingerprint)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+
+		if err = next(c); err != nil {
+			c.Error(err)
+		}
+
+		// the request may have been cancelled by now, but its outcome still has to be kept
+		ctx = context.Background()
+
+		status := c.Response().Status
+		// a replay can not hand out the cookies of the original response
+		if transientStatus(status) || c.Response().Header().Get(echo.HeaderSetCookie) != "" {
+			if err = m.idempotencyStorage.Delete(ctx, key); err != nil {
+				m.logger.WarnMsg("IdempotencyMiddleware.Delete", err)
+			}
+			return nil
+		}
+
+		response := &models.IdempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: c.Response().Header().Get(echo.HeaderContentType),
+			Body:        recorder.body.Bytes(),
+		}
+		if err = m.idempotencyStorage.Save(ctx, key, response, m.cfg.Idempotency.Expire); err != nil {
+			m.logger.WarnMsg("IdempotencyMiddleware.Save", err)
+		}
+
+		return nil
+	}
+}
+
+func (m *Manager) replay(c echo.Context, key string, fingerprint string) error {
+	response, err := m.idempotencyStorage.Get(c.Request().Context(), key)
+	if err != nil {
+		m.logger.Errorf("IdempotencyMiddleware RequestID: %s, Error: %s", utils.GetRequestID(c), err.Error())
+		return err
+	}
+
+	if response.Fingerprint != fingerprint {
+		return errors.Wrapf(domainErrors.Unprocessable, "%s has already been used with a different request body", constants.IdempotencyKey)
+	}
+
+	if response.Status == 0 {
+		return errors.Wrapf(domainErrors.Conflict, "a request with this %s is still in progress", constants.IdempotencyKey)
+	}
+
+	c.Response().Header().Set(constants.IdempotentReplayed, "true")
+	return c.Blob(response.Status, response.ContentType, response.Body)
+}
+
+// idempotencyScope keeps keys of different routes and users apart, so a user can
+// neither collide with nor read another user's stored response.
+func idempotencyScope(c echo.Context, userID int, key string) string {
+	return hash([]byte(c.Request().URL.Path + "\n" + strconv.Itoa(userID) + "\n" + key))
+}
+
+// transientStatus reports whether a response may turn out differently when the request
+// is simply sent again.
+func transientStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder copies everything written to the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/labstack/echo/v4"
+)
+
+// LocaleMiddleware picks the locale of the response from the Accept-Language header.
+// AuthSessionMiddleware replaces it with the preference of the signed in user.
+func (m *Manager) LocaleMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Add(echo.HeaderVary, constants.AcceptLanguage)
+		setLocale(c, i18n.Negotiate(c.Request().Header.Get(constants.AcceptLanguage)))
+		return next(c)
+	}
+}
+
+func setLocale(c echo.Context, locale string) {
+	c.Response().Header().Set(constants.ContentLanguage, locale)
+	c.SetRequest(c.Request().WithContext(i18n.WithLocale(c.Request().Context(), locale)))
+}
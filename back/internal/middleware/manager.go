@@ -3,6 +3,8 @@ package middleware
 import (
 	"github.com/aakosarev/kanban-board/back/internal/auth"
 	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/idempotency"
+	"github.com/aakosarev/kanban-board/back/internal/ratelimit"
 	"github.com/aakosarev/kanban-board/back/internal/session"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
 	"github.com/labstack/echo/v4"
@@ -10,21 +12,26 @@ import (
 )
 
 type Manager struct {
-	sessionUseCase session.UseCase
-	authUseCase    auth.UseCase
-	cfg            *config.Config
-	origins        []string
-	logger         logger.Logger
+	sessionUseCase     session.UseCase
+	authUseCase        auth.UseCase
+	idempotencyStorage idempotency.Storage
+	rateLimitStorage   ratelimit.Storage
+	cfg                *config.Config
+	origins            []string
+	logger             logger.Logger
 }
 
-func NewManager(sessionUseCase session.UseCase, authUseCase auth.UseCase, cfg *config.Config, origins []string, logger logger.Logger) *Manager {
-	return &Manager{sessionUseCase: sessionUseCase, authUseCase: authUseCase, cfg: cfg, origins: origins, logger: logger}
+func NewManager(sessionUseCase session.UseCase, authUseCase auth.UseCase, idempotencyStorage idempotency.Storage, rateLimitStorage ratelimit.Storage, cfg *config.Config, origins []string, logger logger.Logger) *Manager {
+	return &Manager{sessionUseCase: sessionUseCase, authUseCase: authUseCase, idempotencyStorage: idempotencyStorage, rateLimitStorage: rateLimitStorage, cfg: cfg, origins: origins, logger: logger}
 }
 
 func (m *Manager) RequestLoggerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		start := time.Now()
-		err := next(c)
+		// write the error response first, so the logged status is the one sent
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
 
 		req := c.Request()
 		res := c.Response()
@@ -32,6 +39,6 @@ func (m *Manager) RequestLoggerMiddleware(next echo.HandlerFunc) echo.HandlerFun
 		size := res.Size
 		s := time.Since(start)
 		m.logger.HttpMiddlewareAccessLogger(req.Method, req.URL.String(), status, size, s)
-		return err
+		return nil
 	}
 }
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RateLimitMiddleware limits the requests to a route group with a token bucket per user,
+// or per IP address for requests without a valid session. Every response carries the
+// RateLimit-* headers and requests over the limit fail with 429. The buckets of a group
+// are shared by all instances of the service.
+func (m *Manager) RateLimitMiddleware(group string, policy config.RateLimitPolicy) echo.MiddlewareFunc {
+	period := time.Second * time.Duration(policy.Period)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if policy.Limit == 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			key := m.rateLimitKey(c, group)
+
+			rateLimit, err := m.rateLimitStorage.Take(c.Request().Context(), key, policy.Limit, period)
+			if err != nil {
+				// an unavailable storage must not take the whole service down with it
+				m.logger.Errorf("RateLimitMiddleware RequestID: %s, Error: %s", utils.GetRequestID(c), err.Error())
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(constants.RateLimitPolicy, fmt.Sprintf("%d;w=%d", policy.Limit, policy.Period))
+			header.Set(constants.RateLimitLimit, strconv.Itoa(rateLimit.Limit))
+			header.Set(constants.RateLimitRemaining, strconv.Itoa(rateLimit.Remaining))
+			header.Set(constants.RateLimitReset, strconv.Itoa(ceilSeconds(rateLimit.Reset)))
+
+			if !rateLimit.Allowed {
+				m.logger.Warnf("RateLimitMiddleware RequestID: %s, Key: %s, rate limit exceeded", utils.GetRequestID(c), key)
+				return errors.Wrapf(domainErrors.NewTooManyRequestsError(rateLimit.RetryAfter), "rate limit of %s exceeded", group)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// rateLimitKey identifies the client by the user of its session cookie, if the session
+// is valid, and by its IP address otherwise.
+func (m *Manager) rateLimitKey(c echo.Context, group string) string {
+	if cookie, err := c.Cookie(m.cfg.Session.Name); err == nil {
+		if sess, err := m.sessionUseCase.GetSessionByID(c.Request().Context(), cookie.Value); err == nil {
+			return fmt.Sprintf("%s: user: %d", group, sess.UserID)
+		}
+	}
+	return fmt.Sprintf("%s: ip: %s", group, utils.GetIPAddress(c))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,40 @@
+package models
+
+const (
+	BatchCreateColumn          = "create_column"
+	BatchChangeNameColumn      = "change_name_column"
+	BatchDeleteColumn          = "delete_column"
+	BatchCreateTask            = "create_task"
+	BatchChangeDescriptionTask = "change_description_task"
+	BatchMoveTask              = "move_task"
+	BatchDeleteTask            = "delete_task"
+)
+
+// Batch is a sequence of operations executed in one transaction. An operation with
+// a ref publishes the ID it creates, and later operations may use it through
+// id_ref or column_ref instead of id or column_id.
+type Batch struct {
+	Operations []*BatchOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BatchOperation struct {
+	Op          string `json:"op" validate:"required,oneof=create_column change_name_column delete_column create_task change_description_task move_task delete_task"`
+	Ref         string `json:"ref" validate:"omitempty,max=64"`
+	ID          int    `json:"id" validate:"omitempty,gt=0"`
+	IDRef       string `json:"id_ref" validate:"omitempty,max=64"`
+	ColumnID    int    `json:"column_id" validate:"omitempty,gt=0"`
+	ColumnRef   string `json:"column_ref" validate:"omitempty,max=64"`
+	Name        string `json:"name" validate:"omitempty"`
+	Description string `json:"description" validate:"omitempty"`
+}
+
+type BatchResult struct {
+	Refs       map[string]int          `json:"refs"`
+	Operations []*BatchOperationResult `json:"operations"`
+}
+
+type BatchOperationResult struct {
+	Op     string  `json:"op"`
+	Column *Column `json:"column,omitempty"`
+	Task   *Task   `json:"task,omitempty"`
+}
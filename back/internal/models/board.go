@@ -8,6 +8,8 @@ type Col struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Tasks []*T   `json:"tasks"`
+	// NextCursor is only set on paginated boards that have more tasks in the column.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type T struct {
@@ -0,0 +1,21 @@
+package models
+
+const (
+	BulkOperationMove   = "move"
+	BulkOperationDelete = "delete"
+
+	BulkStatusOK       = "ok"
+	BulkStatusNotFound = "not_found"
+)
+
+type BulkTasks struct {
+	TaskIDs   []int  `json:"task_ids" validate:"required,min=1,max=500,dive,gt=0"`
+	Operation string `json:"operation" validate:"required,oneof=move delete"`
+	ColumnID  int    `json:"column_id" validate:"required_if=Operation move"`
+}
+
+type BulkTaskResult struct {
+	TaskID int    `json:"task_id"`
+	Status string `json:"status"`
+	Task   *Task  `json:"task,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Clone maps the IDs of copied entities to the IDs of their copies.
+type Clone struct {
+	Columns map[int]int `json:"columns"`
+	Tasks   map[int]int `json:"tasks"`
+}
+
+func NewClone() *Clone {
+	return &Clone{Columns: make(map[int]int), Tasks: make(map[int]int)}
+}
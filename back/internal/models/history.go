@@ -0,0 +1,21 @@
+package models
+
+const (
+	HistoryUndo = "undo"
+	HistoryRedo = "redo"
+)
+
+// HistoryAction is one user-visible board mutation. Undo walks its steps backwards
+// from the after to the before state, redo walks them forwards.
+type HistoryAction struct {
+	Steps []*HistoryStep `json:"steps"`
+}
+
+// HistoryStep is the transition of a single column or task. A nil state means
+// the entity does not exist on that side of the step.
+type HistoryStep struct {
+	ColumnBefore *Column `json:"column_before,omitempty"`
+	ColumnAfter  *Column `json:"column_after,omitempty"`
+	TaskBefore   *Task   `json:"task_before,omitempty"`
+	TaskAfter    *Task   `json:"task_after,omitempty"`
+}
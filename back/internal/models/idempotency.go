@@ -0,0 +1,10 @@
+package models
+
+// IdempotentResponse is the stored outcome of a request sent with an Idempotency-Key.
+// A response without a Status belongs to a request that is still being processed.
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+
+	cursorPrefix = "task:"
+)
+
+// TaskPage is one page of a column's tasks. NextCursor is empty on the last page.
+type TaskPage struct {
+	Tasks      []*T   `json:"tasks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// EncodeCursor returns an opaque cursor that points right after the task.
+func EncodeCursor(taskID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(taskID)))
+}
+
+// DecodeCursor returns the task ID of a cursor, or 0 for an empty cursor.
+func DecodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, errors.Errorf("invalid cursor %q", cursor)
+	}
+
+	taskID, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || taskID <= 0 {
+		return 0, errors.Errorf("invalid cursor %q", cursor)
+	}
+
+	return taskID, nil
+}
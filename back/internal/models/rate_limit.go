@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RateLimit is the state of a token bucket after a request took, or failed to take, a
+// token from it. Reset is the time until the bucket is full again and RetryAfter, only
+// set for requests that were not allowed, the time until the next token.
+type RateLimit struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
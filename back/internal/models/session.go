@@ -1,6 +1,21 @@
 package models
 
+import "time"
+
+// Session.SessionID is the secret carried by the session cookie, so it is removed
+// before a session is shown to its user. ID identifies the session to its user
+// instead. Current is only set when listing sessions.
 type Session struct {
-	SessionID string `json:"session_id" redis:"session_id"`
-	UserID    int    `json:"user_id" redis:"user_id"`
+	SessionID  string    `json:"session_id,omitempty" redis:"session_id"`
+	ID         string    `json:"id" redis:"id"`
+	UserID     int       `json:"user_id" redis:"user_id"`
+	IPAddress  string    `json:"ip_address" redis:"ip_address"`
+	UserAgent  string    `json:"user_agent" redis:"user_agent"`
+	CreatedAt  time.Time `json:"created_at" redis:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" redis:"last_seen_at"`
+	Current    bool      `json:"current,omitempty" redis:"-"`
+}
+
+func (s *Session) SanitizeSessionID() {
+	s.SessionID = ""
 }
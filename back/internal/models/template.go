@@ -0,0 +1,14 @@
+package models
+
+type Template struct {
+	ID      int               `json:"id" validate:"omitempty"`
+	UserID  int               `json:"user_id,omitempty" validate:"omitempty"`
+	Slug    string            `json:"slug,omitempty" validate:"omitempty"`
+	Name    string            `json:"name" validate:"required,lte=255"`
+	Columns []*TemplateColumn `json:"columns" validate:"omitempty,dive"`
+}
+
+type TemplateColumn struct {
+	Name  string   `json:"name" validate:"required,lte=255"`
+	Tasks []string `json:"tasks,omitempty" validate:"omitempty"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Kinds of single-use tokens sent to users by email.
+const (
+	TokenPasswordReset = "password-reset"
+	TokenVerification  = "verification"
+	TokenMFA           = "mfa"
+)
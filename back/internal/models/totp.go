@@ -0,0 +1,8 @@
+package models
+
+// TOTPEnrollment is returned when a user starts enabling TOTP. URI is the otpauth URI
+// of the secret, usually shown as a QR code.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
@@ -6,9 +6,14 @@ import (
 )
 
 type User struct {
-	ID       int    `json:"id" validate:"omitempty"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password,omitempty" validate:"omitempty,required,gte=6"`
+	ID            int    `json:"id" validate:"omitempty"`
+	Email         string `json:"email" validate:"required,email"`
+	Password      string `json:"password,omitempty" validate:"omitempty,required,gte=6"`
+	Locale        string `json:"locale,omitempty" validate:"omitempty,oneof=en ru"`
+	EmailVerified bool   `json:"email_verified"`
+	TOTPEnabled   bool   `json:"totp_enabled"`
+	TOTPSecret    string `json:"-"`
+	TOTPLastStep  int64  `json:"-"`
 }
 
 func (u *User) PrepareCreate() error {
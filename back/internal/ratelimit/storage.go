@@ -0,0 +1,13 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"time"
+)
+
+type Storage interface {
+	// Take takes a token from the bucket under key. The bucket holds up to limit tokens,
+	// starts full and is refilled with limit tokens every period.
+	Take(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error)
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"time"
+)
+
+const (
+	basePrefix = "rate-limit"
+)
+
+// newRateLimit describes a bucket left with tokens, which may be a fraction.
+func newRateLimit(allowed bool, tokens float64, limit int, period time.Duration) *models.RateLimit {
+	perToken := float64(period) / float64(limit)
+
+	rateLimit := &models.RateLimit{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(limit) - tokens) * perToken),
+	}
+	if !allowed {
+		rateLimit.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+
+	return rateLimit
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/ratelimit"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	sweepInterval = time.Minute
+)
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+type memoryRateLimitStorage struct {
+	mu         sync.Mutex
+	buckets    map[string]*memoryBucket
+	sweepAt    time.Time
+	basePrefix string
+}
+
+func NewMemoryRateLimitStorage() ratelimit.Storage {
+	return &memoryRateLimitStorage{buckets: make(map[string]*memoryBucket), basePrefix: basePrefix}
+}
+
+func (s *memoryRateLimitStorage) Take(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[s.createKey(key)]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit), updatedAt: now}
+		s.buckets[s.createKey(key)] = b
+	}
+
+	b.tokens = math.Min(float64(limit), b.tokens+float64(now.Sub(b.updatedAt))*float64(limit)/float64(period))
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.fullAt = now.Add(period)
+
+	return newRateLimit(allowed, b.tokens, limit, period), nil
+}
+
+// sweep drops the buckets that are full again, as they are the same as missing ones.
+func (s *memoryRateLimitStorage) sweep(now time.Time) {
+	if now.Before(s.sweepAt) {
+		return
+	}
+	for key, b := range s.buckets {
+		if now.After(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	s.sweepAt = now.Add(sweepInterval)
+}
+
+func (s *memoryRateLimitStorage) createKey(key string) string {
+	return fmt.Sprintf("%s: %s", s.basePrefix, key)
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/ratelimit"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// takeScript refills and takes from a bucket in one step, using the clock of Redis so
+// that instances with skewed clocks share buckets correctly.
+var takeScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or limit
+local updatedAt = tonumber(bucket[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - updatedAt) * limit / period)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", tostring(now))
+redis.call("PEXPIRE", KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+type rateLimitStorage struct {
+	redisClient *redis.Client
+	basePrefix  string
+}
+
+func NewRateLimitStorage(redisClient *redis.Client) ratelimit.Storage {
+	return &rateLimitStorage{redisClient: redisClient, basePrefix: basePrefix}
+}
+
+func (s *rateLimitStorage) Take(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error) {
+	result, err := takeScript.Run(ctx, s.redisClient, []string{s.createKey(key)}, limit, period.Milliseconds()).Slice()
+	if err != nil {
+		return nil, errors.Wrap(err, "rateLimitStorage.Take.takeScript.Run")
+	}
+
+	if len(result) != 2 {
+		return nil, errors.Errorf("rateLimitStorage.Take: unexpected result %v", result)
+	}
+	allowed, allowedOk := result[0].(int64)
+	tokensStr, tokensOk := result[1].(string)
+	if !allowedOk || !tokensOk {
+		return nil, errors.Errorf("rateLimitStorage.Take: unexpected result %v", result)
+	}
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "rateLimitStorage.Take.ParseFloat")
+	}
+
+	return newRateLimit(allowed == 1, tokens, limit, period), nil
+}
+
+func (s *rateLimitStorage) createKey(key string) string {
+	return fmt.Sprintf("%s: %s", s.basePrefix, key)
+}
@@ -0,0 +1,18 @@
+package storage_test
+
+import (
+	rateLimitS "github.com/aakosarev/kanban-board/back/internal/ratelimit/storage"
+	"github.com/aakosarev/kanban-board/back/internal/ratelimit/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/redistest"
+	"testing"
+)
+
+func TestMemoryRateLimitStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Fresh(rateLimitS.NewMemoryRateLimitStorage))
+}
+
+// TestRedisRateLimitStorage runs only when REDIS_ADDR points to a Redis instance.
+func TestRedisRateLimitStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Shared(rateLimitS.NewRateLimitStorage(redistest.Client(t))))
+}
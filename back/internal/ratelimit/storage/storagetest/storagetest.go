@@ -0,0 +1,81 @@
+// Package storagetest is a conformance suite for ratelimit.Storage implementations.
+package storagetest
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/ratelimit"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+// Run runs the suite against the storage returned by newStorage, which is called
+// once per test.
+func Run(t *testing.T, newStorage func(t *testing.T) ratelimit.Storage) {
+	conformance.Run(t, newStorage, []conformance.Test[ratelimit.Storage]{
+		{Name: "Take", Fn: testTake},
+		{Name: "KeysAreSeparate", Fn: testKeysAreSeparate},
+		{Name: "Refill", Fn: testRefill},
+	}...)
+}
+
+func testTake(ctx context.Context, t *testing.T, s ratelimit.Storage) {
+	key := uuid.New().String()
+
+	for want := 2; want >= 0; want-- {
+		rateLimit, err := s.Take(ctx, key, 3, time.Minute)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if !rateLimit.Allowed || rateLimit.Limit != 3 || rateLimit.Remaining != want || rateLimit.RetryAfter != 0 {
+			t.Fatalf("Take = %+v, want allowed with %d remaining", rateLimit, want)
+		}
+		if rateLimit.Reset <= 0 || rateLimit.Reset > time.Minute {
+			t.Fatalf("Take Reset = %v, want at most a minute", rateLimit.Reset)
+		}
+	}
+
+	rateLimit, err := s.Take(ctx, key, 3, time.Minute)
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if rateLimit.Allowed || rateLimit.Remaining != 0 {
+		t.Fatalf("Take of an empty bucket = %+v, want not allowed", rateLimit)
+	}
+	if rateLimit.RetryAfter <= 0 || rateLimit.RetryAfter > 20*time.Second {
+		t.Fatalf("Take RetryAfter = %v, want at most the 20s a token takes", rateLimit.RetryAfter)
+	}
+}
+
+func testKeysAreSeparate(ctx context.Context, t *testing.T, s ratelimit.Storage) {
+	key := uuid.New().String()
+
+	if rateLimit, err := s.Take(ctx, key, 1, time.Minute); err != nil || !rateLimit.Allowed {
+		t.Fatalf("Take = %+v, %v, want allowed", rateLimit, err)
+	}
+	if rateLimit, err := s.Take(ctx, key, 1, time.Minute); err != nil || rateLimit.Allowed {
+		t.Fatalf("second Take = %+v, %v, want not allowed", rateLimit, err)
+	}
+	if rateLimit, err := s.Take(ctx, uuid.New().String(), 1, time.Minute); err != nil || !rateLimit.Allowed {
+		t.Fatalf("Take of another key = %+v, %v, want allowed", rateLimit, err)
+	}
+}
+
+func testRefill(ctx context.Context, t *testing.T, s ratelimit.Storage) {
+	t.Parallel()
+	key := uuid.New().String()
+
+	if rateLimit, err := s.Take(ctx, key, 1, time.Second); err != nil || !rateLimit.Allowed {
+		t.Fatalf("Take = %+v, %v, want allowed", rateLimit, err)
+	}
+	if rateLimit, err := s.Take(ctx, key, 1, time.Second); err != nil || rateLimit.Allowed {
+		t.Fatalf("second Take = %+v, %v, want not allowed", rateLimit, err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if rateLimit, err := s.Take(ctx, key, 1, time.Second); err != nil || !rateLimit.Allowed {
+		t.Fatalf("Take after a period = %+v, %v, want allowed", rateLimit, err)
+	}
+}
@@ -0,0 +1,231 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/server"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// harness serves the full route tree over the memory storages. Its client keeps
+// cookies, so requests after signup or login are authenticated.
+type harness struct {
+	t      *testing.T
+	cfg    *config.Config
+	srv    *httptest.Server
+	client *http.Client
+}
+
+// newHarness serves a test config changed by options.
+func newHarness(t *testing.T, options ...func(cfg *config.Config)) *harness {
+	t.Helper()
+
+	cfg := testConfig()
+	cfg.Mail.OutboxDir = t.TempDir()
+	for _, option := range options {
+		option(cfg)
+	}
+
+	appLogger := logger.NewAppLogger(cfg.Logger)
+	appLogger.InitLogger()
+
+	handler, err := server.NewServer(cfg, appLogger, nil, nil).Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+
+	return &harness{t: t, cfg: cfg, srv: srv, client: &http.Client{Jar: jar}}
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		ServiceName: "kanban_service_test",
+		Http: config.Http{
+			Port:         ":0",
+			BasePath:     "/api/v1",
+			AuthPath:     "/api/v1/auth",
+			ColumnPath:   "/api/v1/column",
+			TaskPath:     "/api/v1/task",
+			BoardPath:    "/api/v1/board",
+			TemplatePath: "/api/v1/template",
+			AllowOrigins: []string{"http://localhost:5173"},
+		},
+		Cookie:        config.Cookie{MaxAge: 3600, HTTPOnly: true},
+		Session:       config.Session{Name: "session-id", Prefix: "api-session", Expire: 3600},
+		History:       config.History{Limit: 50, Expire: 3600},
+		Idempotency:   config.Idempotency{Expire: 3600},
+		PasswordReset: config.PasswordReset{Expire: 3600, ResendInterval: 60, URL: "http://localhost:3000/reset-password"},
+		Verification:  config.Verification{Expire: 3600, ResendInterval: 60, URL: "http://localhost:3000/verify-email"},
+		TOTP:          config.TOTP{Issuer: "Kanban board", LoginExpire: 300},
+		LoginThrottle: config.LoginThrottle{Window: 900, MaxFailures: 5, IPMaxFailures: 100, Lockout: 900},
+		Mail:          config.Mail{Driver: constants.Outbox, From: "no-reply@kanban.local"},
+		Storage:       config.Storage{Driver: constants.Memory},
+		Logger:        &logger.Config{LogLevel: "fatal", Encoder: "console"},
+	}
+}
+
+// do sends body as JSON, decodes a JSON response into out when it is not nil and
+// returns the response status.
+func (h *harness) do(method string, path string, body interface{}, out interface{}, cookies ...*http.Cookie) int {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("json.Marshal: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, h.srv.URL+path, reader)
+	if err != nil {
+		h.t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	client := h.client
+	if len(cookies) > 0 {
+		client = &http.Client{}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	if out != nil && res.StatusCode < http.StatusBadRequest {
+		if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+			h.t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+
+	return res.StatusCode
+}
+
+// patch sends a raw patch document with the given content type.
+func (h *harness) patch(path string, contentType string, body string, out interface{}) int {
+	h.t.Helper()
+
+	req, err := http.NewRequest(http.MethodPatch, h.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		h.t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		h.t.Fatalf("PATCH %s: %v", path, err)
+	}
+	defer res.Body.Close()
+
+	if out != nil && res.StatusCode < http.StatusBadRequest {
+		if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+			h.t.Fatalf("PATCH %s: decode response: %v", path, err)
+		}
+	}
+
+	return res.StatusCode
+}
+
+// send sends a raw body with the given headers through the client and returns the
+// response with its body read.
+func (h *harness) send(method string, path string, header http.Header, body string) (*http.Response, []byte) {
+	h.t.Helper()
+
+	req, err := http.NewRequest(method, h.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		h.t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.Header = header
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatalf("%s %s: read response: %v", method, path, err)
+	}
+
+	return res, b
+}
+
+// expect is do that fails the test on an unexpected status.
+func (h *harness) expect(status int, method string, path string, body interface{}, out interface{}, cookies ...*http.Cookie) {
+	h.t.Helper()
+	if got := h.do(method, path, body, out, cookies...); got != status {
+		h.t.Fatalf("%s %s = %d, want %d", method, path, got, status)
+	}
+}
+
+// sessionCookie returns the session cookie the client currently holds.
+func (h *harness) sessionCookie() *http.Cookie {
+	h.t.Helper()
+	for _, cookie := range h.client.Jar.Cookies(mustParseURL(h.t, h.srv.URL)) {
+		if cookie.Name == h.cfg.Session.Name {
+			return cookie
+		}
+	}
+	h.t.Fatal("no session cookie")
+	return nil
+}
+
+// tokens returns the tokens of the links to linkPath that the outbox holds for the
+// given address. Mail is sent in the background, so it waits until at least count
+// tokens arrive.
+func (h *harness) tokens(to string, linkPath string, count int) []string {
+	h.t.Helper()
+
+	linkRe := regexp.MustCompile(regexp.QuoteMeta(linkPath) + `\?token=([A-Za-z0-9_-]+)`)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		files, err := filepath.Glob(filepath.Join(h.cfg.Mail.OutboxDir, "*-"+to+".eml"))
+		if err != nil {
+			h.t.Fatalf("filepath.Glob: %v", err)
+		}
+
+		var tokens []string
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				h.t.Fatalf("os.ReadFile: %v", err)
+			}
+			if m := linkRe.FindSubmatch(b); m != nil {
+				tokens = append(tokens, string(m[1]))
+			}
+		}
+
+		if len(tokens) >= count || time.Now().After(deadline) {
+			return tokens
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"time"
@@ -15,7 +17,6 @@ const (
 )
 
 func (s *Server) runHttpServer() error {
-	s.mapRoutes()
 	s.echo.Server.ReadTimeout = readTimeout
 	s.echo.Server.WriteTimeout = writeTimeout
 	s.echo.Server.MaxHeaderBytes = maxHeaderBytes
@@ -24,10 +25,20 @@ func (s *Server) runHttpServer() error {
 }
 
 func (s *Server) mapRoutes() {
+	s.echo.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(s.log, s.cfg.Http.DebugErrorsResponse)
+
+	// client addresses limit logins, so X-Forwarded-For is only trusted behind a proxy
+	s.echo.IPExtractor = echo.ExtractIPDirect()
+	if s.cfg.Http.TrustProxy {
+		s.echo.IPExtractor = echo.ExtractIPFromXFFHeader()
+	}
+
 	s.echo.Use(s.m.RequestLoggerMiddleware)
 	s.echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderXRequestID},
+		AllowOrigins:     s.cfg.Http.AllowOrigins,
+		AllowCredentials: true,
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderXRequestID, constants.IdempotencyKey, constants.AcceptLanguage},
+		ExposeHeaders:    []string{echo.HeaderRetryAfter, constants.RateLimitLimit, constants.RateLimitRemaining, constants.RateLimitReset, constants.RateLimitPolicy},
 	}))
 	s.echo.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
 		StackSize:         stackSize,
@@ -35,5 +46,6 @@ func (s *Server) mapRoutes() {
 		DisableStackAll:   true,
 	}))
 	s.echo.Use(middleware.RequestID())
+	s.echo.Use(s.m.LocaleMiddleware)
 	s.echo.Use(middleware.BodyLimit(bodyLimit))
 }
@@ -3,18 +3,19 @@ package server
 import (
 	"context"
 	authHttp "github.com/aakosarev/kanban-board/back/internal/auth/delivery/http"
-	authS "github.com/aakosarev/kanban-board/back/internal/auth/storage"
 	authUC "github.com/aakosarev/kanban-board/back/internal/auth/usecase"
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	kanbanHttp "github.com/aakosarev/kanban-board/back/internal/kanban/delivery/http"
-	kanbanS "github.com/aakosarev/kanban-board/back/internal/kanban/storage"
 	kanbanUC "github.com/aakosarev/kanban-board/back/internal/kanban/usecase"
 	"github.com/aakosarev/kanban-board/back/internal/middleware"
-	sessionS "github.com/aakosarev/kanban-board/back/internal/session/storage"
 	sessionUC "github.com/aakosarev/kanban-board/back/internal/session/usecase"
+	templateHttp "github.com/aakosarev/kanban-board/back/internal/template/delivery/http"
+	templateUC "github.com/aakosarev/kanban-board/back/internal/template/usecase"
 	"github.com/aakosarev/kanban-board/back/pkg/logger"
-	"github.com/go-playground/validator"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/aakosarev/kanban-board/back/pkg/mailer"
+	"github.com/aakosarev/kanban-board/back/pkg/postgres"
+	"github.com/aakosarev/kanban-board/back/pkg/validation"
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
@@ -32,38 +33,22 @@ type Server struct {
 	m              *middleware.Manager
 	ps             *http.Server
 	redisClient    *redis.Client
-	postgresClient *pgxpool.Pool
+	postgresClient postgres.Client
 	doneCh         chan struct{}
 }
 
-func NewServer(cfg *config.Config, log logger.Logger, redisClient *redis.Client, postgresClient *pgxpool.Pool) *Server {
-	return &Server{cfg: cfg, log: log, v: validator.New(), redisClient: redisClient, postgresClient: postgresClient, echo: echo.New(), doneCh: make(chan struct{})}
+func NewServer(cfg *config.Config, log logger.Logger, redisClient *redis.Client, postgresClient postgres.Client) *Server {
+	return &Server{cfg: cfg, log: log, v: validation.Validator(), redisClient: redisClient, postgresClient: postgresClient, echo: echo.New(), doneCh: make(chan struct{})}
 }
 
 func (s *Server) Run() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	if err := s.v.StructCtx(ctx, s.cfg); err != nil {
-		return errors.Wrap(err, "cfg validate")
+	if _, err := s.Build(); err != nil {
+		return err
 	}
 
-	sessionStorage := sessionS.NewSessionStorage(s.redisClient, s.cfg)
-	authStorage := authS.NewAuthStorage(s.log, s.postgresClient)
-	kanbanStorage := kanbanS.NewKanbanStorage(s.log, s.postgresClient)
-
-	authUseCase := authUC.NewAuthUseCase(s.cfg, authStorage, s.log)
-	sessionUseCase := sessionUC.NewSessionUseCase(sessionStorage, s.cfg)
-	kanbanUseCase := kanbanUC.NewKanbanUseCase(s.cfg, kanbanStorage, s.log)
-
-	authHandlers := authHttp.NewAuthHandlers(s.echo.Group(s.cfg.Http.AuthPath), s.log, s.cfg, s.v, authUseCase, sessionUseCase)
-	kanbanHandlers := kanbanHttp.NewKanbanHandlers(s.echo.Group(s.cfg.Http.TaskPath), s.echo.Group(s.cfg.Http.ColumnPath), s.echo.Group(s.cfg.Http.BoardPath), s.log, s.cfg, s.v, kanbanUseCase)
-
-	s.m = middleware.NewManager(sessionUseCase, authUseCase, s.cfg, []string{"*"}, s.log)
-
-	authHandlers.MapRoutes()
-	kanbanHandlers.MapRoutes()
-
 	go func() {
 		if err := s.runHttpServer(); err != nil {
 			s.log.Errorf("(s.runHttpServer) err: {%v}", err)
@@ -83,3 +68,36 @@ func (s *Server) Run() error {
 	s.log.Infof("%s server exited properly", GetMicroserviceName(s.cfg))
 	return nil
 }
+
+// Build wires storages, use cases, middlewares and handlers into the Echo instance
+// without listening on a port, so the route tree can also be served by httptest.
+func (s *Server) Build() (http.Handler, error) {
+	if err := s.v.Struct(s.cfg); err != nil {
+		return nil, errors.Wrap(err, "cfg validate")
+	}
+
+	st := s.initStorages()
+
+	authUseCase := authUC.NewAuthUseCase(s.cfg, st.txManager, st.auth, st.authTokens, st.attempts, mailer.NewMailer(s.cfg, s.log), s.log)
+	sessionUseCase := sessionUC.NewSessionUseCase(st.session, s.cfg)
+	kanbanUseCase := kanbanUC.NewKanbanUseCase(s.cfg, st.txManager, st.kanban, st.history, st.boardCache, s.log)
+	templateUseCase := templateUC.NewTemplateUseCase(s.cfg, st.template, st.kanban, st.history, st.boardCache, s.log)
+
+	s.m = middleware.NewManager(sessionUseCase, authUseCase, st.idempotency, st.rateLimit, s.cfg, []string{"*"}, s.log)
+
+	authLimit := s.m.RateLimitMiddleware("auth", s.cfg.RateLimit.Auth)
+	boardLimit := s.m.RateLimitMiddleware("board", s.cfg.RateLimit.Board)
+	templateLimit := s.m.RateLimitMiddleware("template", s.cfg.RateLimit.Template)
+
+	authHandlers := authHttp.NewAuthHandlers(s.echo.Group(s.cfg.Http.AuthPath, authLimit), s.m, s.log, s.cfg, s.v, authUseCase, sessionUseCase)
+	kanbanHandlers := kanbanHttp.NewKanbanHandlers(s.echo.Group(s.cfg.Http.TaskPath, boardLimit), s.echo.Group(s.cfg.Http.ColumnPath, boardLimit), s.echo.Group(s.cfg.Http.BoardPath, boardLimit), s.m, s.log, s.cfg, s.v, kanbanUseCase)
+	templateHandlers := templateHttp.NewTemplateHandlers(s.echo.Group(s.cfg.Http.TemplatePath, templateLimit, s.m.AuthSessionMiddleware), s.m, s.log, s.cfg, s.v, templateUseCase)
+
+	authHandlers.MapRoutes()
+	kanbanHandlers.MapRoutes()
+	templateHandlers.MapRoutes()
+
+	s.mapRoutes()
+
+	return s.echo, nil
+}
@@ -0,0 +1,1184 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/totp"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBoardFlow(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "flow@example.com", "password": "secret1"}, user)
+	if user.ID == 0 || user.Password != "" {
+		t.Fatalf("signup returned %+v", user)
+	}
+
+	todo := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, todo)
+	done := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "done"}, done)
+
+	first := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: todo.ID, Description: "first"}, first)
+	second := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: todo.ID, Description: "second"}, second)
+
+	moved := &models.Task{}
+	h.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/move", first.ID), map[string]int{"column_id": done.ID}, moved)
+	if moved.ColumnID != done.ID {
+		t.Fatalf("moved task = %+v, want column %d", moved, done.ID)
+	}
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 2 {
+		t.Fatalf("board has %d columns, want 2", len(board.Columns))
+	}
+	if tasks := board.Columns[0].Tasks; len(tasks) != 1 || tasks[0].ID != second.ID {
+		t.Errorf("todo tasks = %v, want only task %d", tasks, second.ID)
+	}
+	if tasks := board.Columns[1].Tasks; len(tasks) != 1 || tasks[0].ID != first.ID {
+		t.Errorf("done tasks = %v, want only task %d", tasks, first.ID)
+	}
+
+	cookie := h.sessionCookie()
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+
+	// the logged out session must be rejected even if the client still sends it
+	h.expect(http.StatusUnauthorized, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/move", first.ID), map[string]int{"column_id": todo.ID}, nil, cookie)
+	h.expect(http.StatusUnauthorized, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/move", first.ID), map[string]int{"column_id": todo.ID}, nil)
+
+	// boards are only served to their owner, and no longer by user ID
+	h.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/board", nil, nil)
+	h.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/api/v1/board/%d", user.ID), nil, nil)
+}
+
+func TestLogin(t *testing.T) {
+	h := newHarness(t)
+
+	credentials := map[string]string{"email": "login@example.com", "password": "secret1"}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", credentials, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+
+	h.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/template", nil, nil, &http.Cookie{Name: h.cfg.Session.Name, Value: "api-session: forged"})
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login", map[string]string{"email": credentials["email"], "password": "wrong-password"}, nil)
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login", map[string]string{"email": "nobody@example.com", "password": "secret1"}, nil)
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", credentials, nil)
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/template", nil, nil)
+}
+
+func TestSignupDuplicateEmail(t *testing.T) {
+	h := newHarness(t)
+
+	credentials := map[string]string{"email": "twice@example.com", "password": "secret1"}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", credentials, nil)
+	h.expect(http.StatusConflict, http.MethodPost, "/api/v1/auth/signup", credentials, nil)
+}
+
+func TestErrorResponses(t *testing.T) {
+	h := newHarness(t)
+
+	cases := []struct {
+		method, path string
+		body         interface{}
+		status       int
+	}{
+		{http.MethodGet, "/api/v1/column/0/tasks", nil, http.StatusUnauthorized},
+		{http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "not-an-email", "password": "secret1"}, http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/column/create", map[string]string{"name": "no user"}, http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/no-such-route", nil, http.StatusNotFound},
+		{http.MethodGet, "/api/v1/template", nil, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		b, err := json.Marshal(tc.body)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+
+		req, err := http.NewRequest(tc.method, h.srv.URL+tc.path, bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("http.NewRequest: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := h.client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+
+		problem := &httpErrors.Problem{}
+		err = json.NewDecoder(res.Body).Decode(problem)
+		res.Body.Close()
+
+		if res.StatusCode != tc.status || err != nil || problem.Status != tc.status || problem.Type == "" || problem.Title == "" {
+			t.Errorf("%s %s = %d %+v (decode err %v), want %d", tc.method, tc.path, res.StatusCode, problem, err, tc.status)
+		}
+		if contentType := res.Header.Get("Content-Type"); contentType != httpErrors.ProblemContentType {
+			t.Errorf("%s %s Content-Type = %q", tc.method, tc.path, contentType)
+		}
+		if problem.Instance == "" || problem.Instance != res.Header.Get("X-Request-Id") {
+			t.Errorf("%s %s instance = %q, request ID %q", tc.method, tc.path, problem.Instance, res.Header.Get("X-Request-Id"))
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	h := newHarness(t)
+
+	preflight := func(origin string) *http.Response {
+		t.Helper()
+		res, _ := h.send(http.MethodOptions, "/api/v1/board", http.Header{
+			"Origin":                        {origin},
+			"Access-Control-Request-Method": {http.MethodPatch},
+		}, "")
+		return res
+	}
+
+	// credentials are only allowed for a listed origin, never for any origin
+	res := preflight("http://localhost:5173")
+	if res.Header.Get("Access-Control-Allow-Origin") != "http://localhost:5173" || res.Header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("preflight from the frontend = %v", res.Header)
+	}
+	if res = preflight("http://evil.example"); res.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("preflight from another origin = %v", res.Header)
+	}
+}
+
+func TestValidationProblem(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "problem@example.com", "password": "secret1"}, user)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+
+	req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/api/v1/column/%d", h.srv.URL, column.ID), strings.NewReader(`{"id":1000,"user_id":1000}`))
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		t.Fatalf("PATCH: %v", err)
+	}
+	defer res.Body.Close()
+
+	problem := &httpErrors.Problem{}
+	if err = json.NewDecoder(res.Body).Decode(problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+
+	if problem.Status != http.StatusBadRequest || problem.Type != httpErrors.TypeValidation || len(problem.Errors) != 2 {
+		t.Fatalf("problem = %+v", problem)
+	}
+	if problem.Errors[0].Field != "id" || problem.Errors[1].Field != "user_id" {
+		t.Fatalf("problem errors = %+v", problem.Errors)
+	}
+	// the test config keeps internal causes hidden
+	if problem.Detail != "" {
+		t.Fatalf("problem detail = %q", problem.Detail)
+	}
+
+	b, err := json.Marshal(map[string]string{"email": "not-an-email", "password": "short"})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	res, err = h.client.Post(h.srv.URL+"/api/v1/auth/signup", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("POST /auth/signup: %v", err)
+	}
+	defer res.Body.Close()
+
+	problem = &httpErrors.Problem{}
+	if err = json.NewDecoder(res.Body).Decode(problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+
+	want := []domainErrors.FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "password", Rule: "gte", Param: "6", Message: "password must be at least 6 characters in length"},
+	}
+	if res.StatusCode != http.StatusBadRequest || !reflect.DeepEqual(problem.Errors, want) {
+		t.Fatalf("signup problem = %d %+v, want %+v", res.StatusCode, problem.Errors, want)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	h := newHarness(t)
+
+	russian := http.Header{"Content-Type": {"application/json"}, "Accept-Language": {"ru-RU,ru;q=0.9,en;q=0.8"}}
+
+	res, b := h.send(http.MethodPost, "/api/v1/auth/signup", russian, `{"email":"locale@example.com","password":"secret1"}`)
+	user := &models.User{}
+	if err := json.Unmarshal(b, user); err != nil || res.StatusCode != http.StatusCreated || user.Locale != "ru" {
+		t.Fatalf("signup = %d %s", res.StatusCode, b)
+	}
+
+	problem := func(res *http.Response, b []byte) *httpErrors.Problem {
+		t.Helper()
+		p := &httpErrors.Problem{}
+		if err := json.Unmarshal(b, p); err != nil {
+			t.Fatalf("decode problem %s: %v", b, err)
+		}
+		return p
+	}
+
+	res, b = h.send(http.MethodGet, "/api/v1/template/999", http.Header{"Accept-Language": {"en"}}, "")
+	if p := problem(res, b); p.Status != http.StatusNotFound || p.Title != "Не найдено" || res.Header.Get("Content-Language") != "ru" {
+		t.Fatalf("the user's preference is not used: %+v, Content-Language %q", p, res.Header.Get("Content-Language"))
+	}
+
+	h.expect(http.StatusOK, http.MethodPut, "/api/v1/auth/locale", map[string]string{"locale": "en"}, nil)
+	h.expect(http.StatusBadRequest, http.MethodPut, "/api/v1/auth/locale", map[string]string{"locale": "de"}, nil)
+
+	res, b = h.send(http.MethodGet, "/api/v1/template/999", russian, "")
+	if p := problem(res, b); p.Title != "Not found" {
+		t.Fatalf("title after switching to English = %q", p.Title)
+	}
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+
+	res, b = h.send(http.MethodPost, "/api/v1/auth/signup", russian, `{"email":"not-an-email","password":"secret1"}`)
+	if p := problem(res, b); p.Title != "Некорректное поле" || len(p.Errors) != 1 || !strings.Contains(p.Errors[0].Message, "email адресом") {
+		t.Fatalf("anonymous problem = %+v", p)
+	}
+
+	res, b = h.send(http.MethodGet, "/api/v1/column/abc/tasks", http.Header{"Accept-Language": {"fr"}}, "")
+	if p := problem(res, b); p.Title != "Unauthorized" || res.Header.Get("Content-Language") != "en" {
+		t.Fatalf("unsupported language problem = %+v", p)
+	}
+}
+
+func TestTasksPagination(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "pages@example.com", "password": "secret1"}, user)
+
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+	for i := 0; i < 5; i++ {
+		h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: fmt.Sprintf("task %d", i)}, nil)
+	}
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board?limit=2", nil, board)
+	if len(board.Columns) != 1 || len(board.Columns[0].Tasks) != 2 || board.Columns[0].NextCursor == "" {
+		t.Fatalf("first board page = %+v", board.Columns[0])
+	}
+
+	var descriptions []string
+	cursor := board.Columns[0].NextCursor
+	for cursor != "" {
+		page := &struct {
+			Tasks      []map[string]interface{} `json:"tasks"`
+			NextCursor string                   `json:"next_cursor"`
+		}{}
+		h.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/column/%d/tasks?limit=2&fields=description&cursor=%s", column.ID, cursor), nil, page)
+		for _, task := range page.Tasks {
+			if len(task) != 1 {
+				t.Fatalf("sparse task = %v, want only the description", task)
+			}
+			descriptions = append(descriptions, task["description"].(string))
+		}
+		cursor = page.NextCursor
+	}
+
+	if fmt.Sprint(descriptions) != "[task 2 task 3 task 4]" {
+		t.Fatalf("remaining tasks = %v", descriptions)
+	}
+
+	h.expect(http.StatusBadRequest, http.MethodGet, fmt.Sprintf("/api/v1/column/%d/tasks?cursor=garbage", column.ID), nil, nil)
+	h.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/board?fields=title", nil, nil)
+	h.expect(http.StatusNotFound, http.MethodGet, "/api/v1/column/0/tasks", nil, nil)
+	h.expect(http.StatusBadRequest, http.MethodGet, "/api/v1/column/abc/tasks", nil, nil)
+
+	// another user can't page through the column, and neither can an anonymous client
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "pages2@example.com", "password": "secret1"}, nil)
+	h.expect(http.StatusForbidden, http.MethodGet, fmt.Sprintf("/api/v1/column/%d/tasks", column.ID), nil, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+	h.expect(http.StatusUnauthorized, http.MethodGet, fmt.Sprintf("/api/v1/column/%d/tasks", column.ID), nil, nil)
+}
+
+func TestPatch(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "patch@example.com", "password": "secret1"}, user)
+
+	todo := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, todo)
+	done := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "done"}, done)
+	task := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: todo.ID, Description: "draft"}, task)
+
+	taskPath := fmt.Sprintf("/api/v1/task/%d", task.ID)
+	columnPath := fmt.Sprintf("/api/v1/column/%d", todo.ID)
+
+	patched := &models.Task{}
+	if status := h.patch(taskPath, "application/merge-patch+json", `{"description":"final"}`, patched); status != http.StatusOK {
+		t.Fatalf("merge patch = %d", status)
+	}
+	if *patched != (models.Task{ID: task.ID, ColumnID: todo.ID, Description: "final"}) {
+		t.Fatalf("merge patched task = %+v", patched)
+	}
+
+	patch := fmt.Sprintf(`[{"op":"test","path":"/description","value":"final"},{"op":"replace","path":"/column_id","value":%d}]`, done.ID)
+	if status := h.patch(taskPath, "application/json-patch+json", patch, patched); status != http.StatusOK {
+		t.Fatalf("json patch = %d", status)
+	}
+	if *patched != (models.Task{ID: task.ID, ColumnID: done.ID, Description: "final"}) {
+		t.Fatalf("json patched task = %+v", patched)
+	}
+
+	column := &models.Column{}
+	if status := h.patch(columnPath, "application/merge-patch+json", `{"name":"backlog"}`, column); status != http.StatusOK {
+		t.Fatalf("column merge patch = %d", status)
+	}
+	if column.Name != "backlog" || column.UserID != user.ID {
+		t.Fatalf("patched column = %+v", column)
+	}
+
+	failures := []struct {
+		path, contentType, body string
+		status                  int
+	}{
+		{taskPath, "application/json", `{"description":"x"}`, http.StatusUnsupportedMediaType},
+		{taskPath, "application/merge-patch+json", `{"title":"x"}`, http.StatusBadRequest},
+		{taskPath, "application/merge-patch+json", `{"description":5}`, http.StatusBadRequest},
+		{taskPath, "application/merge-patch+json", `{"id":999}`, http.StatusBadRequest},
+		{taskPath, "application/json-patch+json", `[{"op":"test","path":"/description","value":"draft"}]`, http.StatusBadRequest},
+		{columnPath, "application/merge-patch+json", `{"user_id":null}`, http.StatusBadRequest},
+		{columnPath, "application/json-patch+json", `[{"op":"replace","path":"/user_id","value":999}]`, http.StatusBadRequest},
+		{"/api/v1/task/0", "application/merge-patch+json", `{"description":"x"}`, http.StatusNotFound},
+	}
+	for _, f := range failures {
+		if status := h.patch(f.path, f.contentType, f.body, nil); status != f.status {
+			t.Errorf("PATCH %s %s %s = %d, want %d", f.path, f.contentType, f.body, status, f.status)
+		}
+	}
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if board.Columns[0].Name != "backlog" || len(board.Columns[1].Tasks) != 1 || board.Columns[1].Tasks[0].Description != "final" {
+		t.Fatalf("board after failed patches = %+v %+v", board.Columns[0], board.Columns[1])
+	}
+
+	owner := h.sessionCookie()
+	other := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "patch-other@example.com", "password": "secret1"}, other)
+	foreign := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "foreign"}, foreign)
+
+	forbidden := []struct {
+		path, body string
+		status     int
+	}{
+		{taskPath, `{"description":"stolen"}`, http.StatusForbidden},
+		{columnPath, `{"name":"stolen"}`, http.StatusForbidden},
+		{taskPath + "/update_description", `{"description":"stolen"}`, http.StatusNotFound},
+		{columnPath + "/update_name", `{"name":"stolen"}`, http.StatusNotFound},
+	}
+	for _, f := range forbidden {
+		if status := h.patch(f.path, "application/merge-patch+json", f.body, nil); status != f.status {
+			t.Errorf("PATCH %s by another user = %d, want %d", f.path, status, f.status)
+		}
+	}
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+	if status := h.patch(taskPath, "application/merge-patch+json", `{"description":"anonymous"}`, nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous task PATCH = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status := h.patch(columnPath, "application/merge-patch+json", `{"name":"anonymous"}`, nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous column PATCH = %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	h.client.Jar.SetCookies(mustParseURL(t, h.srv.URL), []*http.Cookie{owner})
+	if status := h.patch(taskPath, "application/merge-patch+json", fmt.Sprintf(`{"column_id":%d}`, foreign.ID), nil); status != http.StatusForbidden {
+		t.Errorf("PATCH moving the task onto another board = %d, want %d", status, http.StatusForbidden)
+	}
+
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if board.Columns[0].Name != "backlog" || len(board.Columns[1].Tasks) != 1 || board.Columns[1].Tasks[0].Description != "final" {
+		t.Fatalf("board after rejected patches = %+v %+v", board.Columns[0], board.Columns[1])
+	}
+}
+
+func TestClone(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "clone@example.com", "password": "secret1"}, user)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+	task := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: "task"}, task)
+
+	clone := &models.Clone{}
+	h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/api/v1/column/%d/clone", column.ID), nil, clone)
+	if clone.Columns[column.ID] == 0 || clone.Tasks[task.ID] == 0 {
+		t.Fatalf("column clone = %+v, want column %d and task %d", clone, column.ID, task.ID)
+	}
+	clone = &models.Clone{}
+	h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/clone", task.ID), nil, clone)
+	if clone.Tasks[task.ID] == 0 {
+		t.Fatalf("task clone = %+v, want task %d", clone, task.ID)
+	}
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 2 || len(board.Columns[0].Tasks) != 2 || len(board.Columns[1].Tasks) != 1 {
+		t.Fatalf("board after cloning = %+v", board)
+	}
+
+	owner := h.sessionCookie()
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "clone-other@example.com", "password": "secret1"}, nil)
+
+	h.expect(http.StatusForbidden, http.MethodPost, fmt.Sprintf("/api/v1/column/%d/clone", column.ID), nil, nil)
+	h.expect(http.StatusForbidden, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/clone", task.ID), nil, nil)
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+	h.expect(http.StatusUnauthorized, http.MethodPost, fmt.Sprintf("/api/v1/column/%d/clone", column.ID), nil, nil)
+	h.expect(http.StatusUnauthorized, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/clone", task.ID), nil, nil)
+
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board, owner)
+	if len(board.Columns) != 2 {
+		t.Fatalf("board has %d columns after the rejected clones, want 2", len(board.Columns))
+	}
+}
+
+func TestWriteOwnership(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "owner@example.com", "password": "secret1"}, user)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+	task := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: "task"}, task)
+
+	owner := h.sessionCookie()
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "owner-other@example.com", "password": "secret1"}, nil)
+
+	writes := func(status int) {
+		t.Helper()
+		h.expect(status, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: "intruder"}, nil)
+		h.expect(status, http.MethodDelete, fmt.Sprintf("/api/v1/task/%d", task.ID), nil, nil)
+		h.expect(status, http.MethodDelete, fmt.Sprintf("/api/v1/column/%d", column.ID), nil, nil)
+	}
+	writes(http.StatusForbidden)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+	writes(http.StatusUnauthorized)
+
+	// only the owner's own writes are in their history
+	h.client.Jar.SetCookies(mustParseURL(t, h.srv.URL), []*http.Cookie{owner})
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 1 || len(board.Columns[0].Tasks) != 1 {
+		t.Fatalf("board after the rejected writes = %+v", board)
+	}
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/board/undo", nil, nil)
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 1 || len(board.Columns[0].Tasks) != 0 {
+		t.Fatalf("board after undo = %+v, want the task removed", board)
+	}
+
+	h.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/api/v1/column/%d", column.ID), nil, nil)
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 0 {
+		t.Fatalf("board after deleting the column = %+v", board)
+	}
+}
+
+func TestTransferAcrossBoards(t *testing.T) {
+	h := newHarness(t)
+
+	other := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "transfer-other@example.com", "password": "secret1"}, other)
+	otherSession := h.sessionCookie()
+	foreign := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "foreign"}, foreign)
+	foreignTask := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: foreign.ID, Description: "foreign"}, foreignTask)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "transfer@example.com", "password": "secret1"}, user)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+	task := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: "task"}, task)
+
+	for _, action := range []string{"move", "copy"} {
+		h.expect(http.StatusForbidden, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/%s", task.ID, action), map[string]int{"column_id": foreign.ID}, nil)
+		h.expect(http.StatusForbidden, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/%s", foreignTask.ID, action), map[string]int{"column_id": column.ID}, nil)
+	}
+	h.expect(http.StatusNotFound, http.MethodPatch, fmt.Sprintf("/api/v1/task/%d/update_column_id", task.ID), map[string]int{"column_id": foreign.ID}, nil)
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board, otherSession)
+	if len(board.Columns) != 1 || len(board.Columns[0].Tasks) != 1 || board.Columns[0].Tasks[0].ID != foreignTask.ID {
+		t.Fatalf("other board after the rejected transfers = %+v", board)
+	}
+}
+
+func TestBulkDuplicateTasks(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "bulk@example.com", "password": "secret1"}, user)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+	task := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: "task"}, task)
+
+	b, err := json.Marshal(models.BulkTasks{TaskIDs: []int{task.ID, task.ID}, Operation: models.BulkOperationDelete})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	res, body := h.send(http.MethodPost, "/api/v1/task/bulk", http.Header{"Content-Type": {"application/json"}}, string(b))
+
+	problem := &httpErrors.Problem{}
+	if err = json.Unmarshal(body, problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if res.StatusCode != http.StatusBadRequest || len(problem.Errors) != 1 || problem.Errors[0].Field != "task_ids" || problem.Errors[0].Rule != "unique" {
+		t.Fatalf("bulk with duplicate task IDs = %d %+v", res.StatusCode, problem)
+	}
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 1 || len(board.Columns[0].Tasks) != 1 {
+		t.Fatalf("board after the rejected bulk = %+v", board)
+	}
+}
+
+func TestBatchRefKinds(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "batch@example.com", "password": "secret1"}, user)
+
+	result := &models.BatchResult{}
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/board/batch", models.Batch{Operations: []*models.BatchOperation{
+		{Op: models.BatchCreateColumn, Ref: "todo", Name: "todo"},
+		{Op: models.BatchCreateTask, Ref: "first", ColumnRef: "todo", Description: "first"},
+		{Op: models.BatchChangeDescriptionTask, IDRef: "first", Description: "renamed"},
+	}}, result)
+	if len(result.Refs) != 2 || result.Operations[2].Task == nil || result.Operations[2].Task.Description != "renamed" {
+		t.Fatalf("batch = %+v", result)
+	}
+
+	// a task ref is never taken for a column, and a column ref never for a task
+	mismatches := [][]*models.BatchOperation{
+		{
+			{Op: models.BatchCreateColumn, Ref: "done", Name: "done"},
+			{Op: models.BatchCreateTask, Ref: "task", ColumnRef: "done", Description: "task"},
+			{Op: models.BatchCreateTask, ColumnRef: "task", Description: "nested"},
+		},
+		{
+			{Op: models.BatchCreateColumn, Ref: "done", Name: "done"},
+			{Op: models.BatchDeleteTask, IDRef: "done"},
+		},
+	}
+	for i, operations := range mismatches {
+		if status := h.do(http.MethodPost, "/api/v1/board/batch", models.Batch{Operations: operations}, nil); status != http.StatusBadRequest {
+			t.Errorf("batch %d with a ref of the wrong kind = %d, want %d", i, status, http.StatusBadRequest)
+		}
+	}
+
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, board)
+	if len(board.Columns) != 1 || len(board.Columns[0].Tasks) != 1 {
+		t.Fatalf("board after the rejected batches = %+v", board)
+	}
+}
+
+func TestTemplateBoardUndo(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "template-undo@example.com", "password": "secret1"}, user)
+
+	created := &models.Board{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/template/builtin/kanban/create_board", nil, created)
+	if len(created.Columns) == 0 {
+		t.Fatal("builtin template created no columns")
+	}
+
+	boardPath := "/api/v1/board"
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/board/undo", nil, nil)
+	board := &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, boardPath, nil, board)
+	if len(board.Columns) != 0 {
+		t.Fatalf("board after undo = %+v, want no columns", board)
+	}
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/board/redo", nil, nil)
+	board = &models.Board{}
+	h.expect(http.StatusOK, http.MethodGet, boardPath, nil, board)
+	if len(board.Columns) != len(created.Columns) {
+		t.Fatalf("board after redo has %d columns, want %d", len(board.Columns), len(created.Columns))
+	}
+	for i, col := range board.Columns {
+		if col.ID != created.Columns[i].ID || len(col.Tasks) != len(created.Columns[i].Tasks) {
+			t.Fatalf("column %d after redo = %+v, want %+v", i, col, created.Columns[i])
+		}
+	}
+}
+
+func TestBoardCache(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Redis.BoardCache = true
+		cfg.Redis.BoardCacheTTL = 60
+	})
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "cache@example.com", "password": "secret1"}, user)
+	todo := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, todo)
+	done := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "done"}, done)
+
+	board := func() *models.Board {
+		t.Helper()
+		b := &models.Board{}
+		h.expect(http.StatusOK, http.MethodGet, "/api/v1/board", nil, b)
+		return b
+	}
+	tasks := func(b *models.Board, column int) []*models.T {
+		for _, col := range b.Columns {
+			if col.ID == column {
+				return col.Tasks
+			}
+		}
+		return nil
+	}
+
+	if got := board(); len(got.Columns) != 2 || len(tasks(got, todo.ID)) != 0 {
+		t.Fatalf("board = %+v, want two empty columns", got)
+	}
+
+	task := &models.Task{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: todo.ID, Description: "task"}, task)
+	if got := tasks(board(), todo.ID); len(got) != 1 || got[0].ID != task.ID {
+		t.Fatalf("todo tasks after a write = %v, want task %d", got, task.ID)
+	}
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/board/undo", nil, nil)
+	if got := tasks(board(), todo.ID); len(got) != 0 {
+		t.Fatalf("todo tasks after undo = %v, want none", got)
+	}
+
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/board/redo", nil, nil)
+	h.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/api/v1/task/%d/move", task.ID), map[string]int{"column_id": done.ID}, nil)
+	got := board()
+	if len(tasks(got, todo.ID)) != 0 || len(tasks(got, done.ID)) != 1 {
+		t.Fatalf("board after moving the task across columns = %+v", got)
+	}
+
+	created := &models.Board{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/template/builtin/kanban/create_board", nil, created)
+	if got = board(); len(got.Columns) != 2+len(created.Columns) {
+		t.Fatalf("board after creating a template board has %d columns, want %d", len(got.Columns), 2+len(created.Columns))
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	h := newHarness(t)
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "retry@example.com", "password": "secret1"}, user)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+
+	create := func(key string, description string) (int, bool, *models.Task) {
+		t.Helper()
+
+		body := fmt.Sprintf(`{"column_id":%d,"description":%q}`, column.ID, description)
+		req, err := http.NewRequest(http.MethodPost, h.srv.URL+"/api/v1/task/create", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("http.NewRequest: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+
+		res, err := h.client.Do(req)
+		if err != nil {
+			t.Fatalf("POST /task/create: %v", err)
+		}
+		defer res.Body.Close()
+
+		task := &models.Task{}
+		if res.StatusCode == http.StatusCreated {
+			if err = json.NewDecoder(res.Body).Decode(task); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+		}
+		return res.StatusCode, res.Header.Get("Idempotent-Replayed") == "true", task
+	}
+
+	status, replayed, first := create("retry-1", "once")
+	if status != http.StatusCreated || replayed {
+		t.Fatalf("first request = %d, replayed %v", status, replayed)
+	}
+
+	status, replayed, retried := create("retry-1", "once")
+	if status != http.StatusCreated || !replayed || *retried != *first {
+		t.Fatalf("retry = %d, replayed %v, %+v, want %+v", status, replayed, retried, first)
+	}
+
+	if status, _, _ = create("retry-1", "twice"); status != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with another body = %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+
+	status, replayed, other := create("retry-2", "once")
+	if status != http.StatusCreated || replayed || other.ID == first.ID {
+		t.Fatalf("new key = %d, replayed %v, %+v", status, replayed, other)
+	}
+
+	tasks := &models.TaskPage{}
+	h.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/api/v1/column/%d/tasks", column.ID), nil, tasks)
+	if len(tasks.Tasks) != 2 {
+		t.Fatalf("column has %d tasks, want 2", len(tasks.Tasks))
+	}
+
+	post := func(client *http.Client, path string, key string, body string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, h.srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("http.NewRequest: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		res.Body.Close()
+		return res
+	}
+
+	// a forged session is turned away before the key is looked at
+	forged := &http.Cookie{Name: h.cfg.Session.Name, Value: "forged"}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+	jar.SetCookies(mustParseURL(t, h.srv.URL), []*http.Cookie{forged})
+	body := fmt.Sprintf(`{"column_id":%d,"description":"once"}`, column.ID)
+	if res := post(&http.Client{Jar: jar}, "/api/v1/task/create", "retry-1", body); res.StatusCode != http.StatusUnauthorized || res.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("forged session = %d, replayed %q", res.StatusCode, res.Header.Get("Idempotent-Replayed"))
+	}
+
+	// keys belong to the user, so a new session of the same user still gets the replay
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", map[string]string{"email": "retry@example.com", "password": "secret1"}, nil)
+	if res := post(h.client, "/api/v1/task/create", "retry-1", body); res.StatusCode != http.StatusCreated || res.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry from a new session = %d, replayed %q", res.StatusCode, res.Header.Get("Idempotent-Replayed"))
+	}
+
+	// and another user's request with the same key runs on its own
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "retry2@example.com", "password": "secret1"}, nil)
+	if res := post(h.client, "/api/v1/task/create", "retry-1", body); res.StatusCode != http.StatusForbidden || res.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("same key from another user = %d, replayed %q", res.StatusCode, res.Header.Get("Idempotent-Replayed"))
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) { cfg.PasswordReset.ResendInterval = 0 })
+
+	credentials := map[string]string{"email": "reset@example.com", "password": "secret1"}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", credentials, nil)
+	session := h.sessionCookie()
+
+	h.expect(http.StatusAccepted, http.MethodPost, "/api/v1/auth/password/reset", map[string]string{"email": "nobody@example.com"}, nil)
+	h.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/password/reset", map[string]string{"email": "not-an-email"}, nil)
+	h.expect(http.StatusAccepted, http.MethodPost, "/api/v1/auth/password/reset", map[string]string{"email": "Reset@Example.com"}, nil)
+	h.expect(http.StatusAccepted, http.MethodPost, "/api/v1/auth/password/reset", map[string]string{"email": credentials["email"]}, nil)
+
+	tokens := h.tokens(credentials["email"], "/reset-password", 2)
+	if len(tokens) != 2 {
+		t.Fatalf("outbox has %d reset links for %s, want 2", len(tokens), credentials["email"])
+	}
+	if others := h.tokens("nobody@example.com", "/reset-password", 0); len(others) != 0 {
+		t.Fatalf("a reset mail was sent to an unknown email")
+	}
+
+	// only the latest link works, and only once
+	confirm := func(token string) int {
+		return h.do(http.MethodPost, "/api/v1/auth/password/reset/confirm", map[string]string{"token": token, "password": "secret2"}, nil)
+	}
+	used := -1
+	for i, token := range tokens {
+		if confirm(token) == http.StatusOK {
+			if used != -1 {
+				t.Fatal("both reset links worked")
+			}
+			used = i
+		}
+	}
+	if used == -1 {
+		t.Fatal("no reset link worked")
+	}
+	if got := confirm(tokens[used]); got != http.StatusBadRequest {
+		t.Fatalf("reusing a reset link = %d, want %d", got, http.StatusBadRequest)
+	}
+	if got := confirm("unknown"); got != http.StatusBadRequest {
+		t.Fatalf("confirming an unknown token = %d, want %d", got, http.StatusBadRequest)
+	}
+
+	if got := h.do(http.MethodGet, "/api/v1/template/999", nil, nil, session); got != http.StatusUnauthorized {
+		t.Fatalf("session after a reset = %d, want %d", got, http.StatusUnauthorized)
+	}
+
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login", credentials, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", map[string]string{"email": credentials["email"], "password": "secret2"}, nil)
+}
+
+func TestPasswordResetThrottle(t *testing.T) {
+	h := newHarness(t)
+
+	email := "throttle-reset@example.com"
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": email, "password": "secret1"}, nil)
+
+	h.expect(http.StatusAccepted, http.MethodPost, "/api/v1/auth/password/reset", map[string]string{"email": email}, nil)
+	if tokens := h.tokens(email, "/reset-password", 1); len(tokens) != 1 {
+		t.Fatalf("outbox has %d reset links for %s, want 1", len(tokens), email)
+	}
+
+	// a throttled request looks the same as one for an unknown email
+	h.expect(http.StatusAccepted, http.MethodPost, "/api/v1/auth/password/reset", map[string]string{"email": email}, nil)
+	if tokens := h.tokens(email, "/reset-password", 0); len(tokens) != 1 {
+		t.Fatalf("outbox has %d reset links for %s after a second request, want 1", len(tokens), email)
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) { cfg.Verification.Required = true })
+
+	user := &models.User{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]interface{}{"email": "verify@example.com", "password": "secret1", "email_verified": true}, user)
+	if user.EmailVerified {
+		t.Fatal("signup verified the email")
+	}
+
+	h.expect(http.StatusForbidden, http.MethodPost, "/api/v1/template/builtin/kanban/create_board", nil, nil)
+	h.expect(http.StatusForbidden, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, nil)
+	h.expect(http.StatusForbidden, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: 1, Description: "task"}, nil)
+	h.expect(http.StatusTooManyRequests, http.MethodPost, "/api/v1/auth/email/verify/resend", nil, nil)
+
+	tokens := h.tokens(user.Email, "/verify-email", 1)
+	if len(tokens) != 1 {
+		t.Fatalf("outbox has %d verification links, want 1", len(tokens))
+	}
+
+	h.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/email/verify", map[string]string{"token": "unknown"}, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/email/verify", map[string]string{"token": tokens[0]}, user)
+	if !user.EmailVerified {
+		t.Fatalf("verify returned %+v", user)
+	}
+	h.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/email/verify", map[string]string{"token": tokens[0]}, nil)
+
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/template/builtin/kanban/create_board", nil, nil)
+	column := &models.Column{}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/column/create", map[string]string{"name": "todo"}, column)
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/task/create", models.Task{ColumnID: column.ID, Description: "task"}, nil)
+	h.expect(http.StatusConflict, http.MethodPost, "/api/v1/auth/email/verify/resend", nil, nil)
+}
+
+func TestTOTP(t *testing.T) {
+	h := newHarness(t)
+
+	credentials := map[string]string{"email": "totp@example.com", "password": "secret1"}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", credentials, nil)
+
+	enrollment := &models.TOTPEnrollment{}
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/totp/enroll", nil, enrollment)
+	if enrollment.Secret == "" || !strings.HasPrefix(enrollment.URI, "otpauth://totp/") {
+		t.Fatalf("enroll returned %+v", enrollment)
+	}
+
+	code := func(at time.Time) string {
+		t.Helper()
+		c, err := totp.Code(enrollment.Secret, at)
+		if err != nil {
+			t.Fatalf("totp.Code: %v", err)
+		}
+		return c
+	}
+	wrongCode := fmt.Sprintf("%06d", (mustAtoi(t, code(time.Now()))+500000)%1000000)
+
+	h.expect(http.StatusBadRequest, http.MethodPost, "/api/v1/auth/totp/confirm", map[string]string{"code": wrongCode}, nil)
+
+	recovery := &struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{}
+	// the code of the previous step is still accepted, so the current one is left for the login
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/totp/confirm", map[string]string{"code": code(time.Now().Add(-totp.Period))}, recovery)
+	if len(recovery.RecoveryCodes) != 10 {
+		t.Fatalf("confirm returned %d recovery codes, want 10", len(recovery.RecoveryCodes))
+	}
+	h.expect(http.StatusConflict, http.MethodPost, "/api/v1/auth/totp/enroll", nil, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+
+	type mfa struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+	login := func() string {
+		t.Helper()
+		pending := &mfa{}
+		h.expect(http.StatusAccepted, http.MethodPost, "/api/v1/auth/login", credentials, pending)
+		if !pending.MFARequired || pending.MFAToken == "" {
+			t.Fatalf("login returned %+v", pending)
+		}
+		return pending.MFAToken
+	}
+
+	token := login()
+	h.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/template/999", nil, nil)
+
+	// a wrong code spends the token
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": token, "code": wrongCode}, nil)
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": token, "code": code(time.Now())}, nil)
+
+	current := code(time.Now())
+	user := &models.User{}
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": login(), "code": current}, user)
+	if user.Email != credentials["email"] || !user.TOTPEnabled {
+		t.Fatalf("login returned %+v", user)
+	}
+	h.expect(http.StatusNotFound, http.MethodGet, "/api/v1/template/999", nil, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout", nil, nil)
+
+	// a used code is not accepted again, and neither is the code of an earlier step
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": login(), "code": current}, nil)
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": login(), "code": code(time.Now().Add(-totp.Period))}, nil)
+
+	recoveryCode := strings.ToUpper(recovery.RecoveryCodes[0])
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": login(), "code": recoveryCode}, nil)
+	h.expect(http.StatusUnauthorized, http.MethodPost, "/api/v1/auth/login/totp", map[string]string{"mfa_token": login(), "code": recoveryCode}, nil)
+}
+
+func TestLoginThrottle(t *testing.T) {
+	loginStatus := func(h *harness, email string, password string) (int, string) {
+		t.Helper()
+		header := http.Header{"Content-Type": {"application/json"}}
+		body := fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)
+		res, _ := h.send(http.MethodPost, "/api/v1/auth/login", header, body)
+		return res.StatusCode, res.Header.Get("Retry-After")
+	}
+
+	t.Run("lockout", func(t *testing.T) {
+		h := newHarness(t)
+		h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "locked@example.com", "password": "secret1"}, nil)
+
+		for i := 0; i < h.cfg.LoginThrottle.MaxFailures; i++ {
+			if status, _ := loginStatus(h, "Locked@Example.com", "wrong-password"); status != http.StatusUnauthorized {
+				t.Fatalf("failure %d = %d, want %d", i+1, status, http.StatusUnauthorized)
+			}
+		}
+		if status, retryAfter := loginStatus(h, "locked@example.com", "secret1"); status != http.StatusTooManyRequests || retryAfter != "900" {
+			t.Fatalf("locked login = %d, Retry-After %q", status, retryAfter)
+		}
+		if status, _ := loginStatus(h, "other@example.com", "wrong-password"); status != http.StatusUnauthorized {
+			t.Fatalf("other account = %d, want %d", status, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		h := newHarness(t, func(cfg *config.Config) {
+			cfg.LoginThrottle.BaseDelay = 2
+			cfg.LoginThrottle.MaxDelay = 60
+		})
+		h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "slow@example.com", "password": "secret1"}, nil)
+
+		if status, _ := loginStatus(h, "slow@example.com", "wrong-password"); status != http.StatusUnauthorized {
+			t.Fatalf("failure = %d, want %d", status, http.StatusUnauthorized)
+		}
+		if status, retryAfter := loginStatus(h, "slow@example.com", "secret1"); status != http.StatusTooManyRequests || retryAfter != "2" {
+			t.Fatalf("delayed login = %d, Retry-After %q", status, retryAfter)
+		}
+	})
+
+	t.Run("ip", func(t *testing.T) {
+		h := newHarness(t, func(cfg *config.Config) {
+			cfg.LoginThrottle.IPMaxFailures = 3
+		})
+
+		for i := 0; i < 3; i++ {
+			if status, _ := loginStatus(h, fmt.Sprintf("user%d@example.com", i), "wrong-password"); status != http.StatusUnauthorized {
+				t.Fatalf("failure %d = %d, want %d", i+1, status, http.StatusUnauthorized)
+			}
+		}
+		if status, _ := loginStatus(h, "user3@example.com", "wrong-password"); status != http.StatusTooManyRequests {
+			t.Fatalf("login from a locked address = %d, want %d", status, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		h := newHarness(t)
+		h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "race@example.com", "password": "secret1"}, nil)
+
+		// every attempt is counted before the password is compared, so parallel
+		// guesses can not get past the limit
+		const attempts = 20
+		statuses := make(chan int, attempts)
+		for i := 0; i < attempts; i++ {
+			go func() {
+				res, err := http.Post(h.srv.URL+"/api/v1/auth/login", "application/json", strings.NewReader(`{"email": "race@example.com", "password": "wrong-password"}`))
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				res.Body.Close()
+				statuses <- res.StatusCode
+			}()
+		}
+
+		counts := make(map[int]int)
+		for i := 0; i < attempts; i++ {
+			counts[<-statuses]++
+		}
+		if counts[http.StatusUnauthorized] != h.cfg.LoginThrottle.MaxFailures || counts[http.StatusTooManyRequests] != attempts-h.cfg.LoginThrottle.MaxFailures {
+			t.Fatalf("parallel logins = %v, want %d compared and the rest throttled", counts, h.cfg.LoginThrottle.MaxFailures)
+		}
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.RateLimit.Auth = config.RateLimitPolicy{Limit: 3, Period: 60}
+	})
+	// anonymous requests take from the bucket of the address
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "limited@example.com", "password": "secret1"}, nil)
+
+	for i := 2; i >= 0; i-- {
+		res, _ := h.send(http.MethodPut, "/api/v1/auth/locale", http.Header{"Content-Type": {"application/json"}}, `{"locale": "en"}`)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("request %d = %d, want %d", 3-i, res.StatusCode, http.StatusOK)
+		}
+		if got := res.Header.Get("RateLimit-Remaining"); got != strconv.Itoa(i) {
+			t.Fatalf("RateLimit-Remaining = %q, want %d", got, i)
+		}
+		if res.Header.Get("RateLimit-Limit") != "3" || res.Header.Get("RateLimit-Policy") != "3;w=60" {
+			t.Fatalf("RateLimit headers = %v", res.Header)
+		}
+	}
+
+	res, _ := h.send(http.MethodPut, "/api/v1/auth/locale", http.Header{"Content-Type": {"application/json"}}, `{"locale": "en"}`)
+	if res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") != "20" || res.Header.Get("RateLimit-Reset") != "60" {
+		t.Fatalf("limited request = %d, headers %v", res.StatusCode, res.Header)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.srv.URL+"/api/v1/auth/password/reset", strings.NewReader(`{"email": "limited@example.com"}`))
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	anonymous, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /api/v1/auth/password/reset: %v", err)
+	}
+	anonymous.Body.Close()
+	if anonymous.StatusCode != http.StatusAccepted || anonymous.Header.Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("anonymous request = %d, headers %v", anonymous.StatusCode, anonymous.Header)
+	}
+
+	// other groups have their own policies, and none is configured here
+	h.expect(http.StatusNotFound, http.MethodGet, "/api/v1/template/999", nil, nil)
+}
+
+func TestSessions(t *testing.T) {
+	h := newHarness(t)
+	credentials := map[string]string{"email": "sessions@example.com", "password": "secret1"}
+	h.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", credentials, nil)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+	other := &harness{t: t, cfg: h.cfg, srv: h.srv, client: &http.Client{Jar: jar}}
+	other.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", credentials, nil)
+
+	var sessions []map[string]interface{}
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/sessions", nil, &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("GET /sessions returned %d sessions, want 2", len(sessions))
+	}
+	var (
+		otherID string
+		current int
+	)
+	for _, sess := range sessions {
+		if _, ok := sess["session_id"]; ok {
+			t.Fatalf("GET /sessions exposes the session cookie: %v", sess)
+		}
+		if sess["ip_address"] != "127.0.0.1" || sess["user_agent"] == "" || sess["created_at"] == nil {
+			t.Fatalf("GET /sessions returned %v, want the session metadata", sess)
+		}
+		if sess["current"] == true {
+			current++
+		} else {
+			otherID = sess["id"].(string)
+		}
+	}
+	if current != 1 || otherID == "" {
+		t.Fatalf("GET /sessions = %v, want exactly one current session", sessions)
+	}
+
+	h.expect(http.StatusNotFound, http.MethodDelete, "/api/v1/auth/sessions/missing", nil, nil)
+	h.expect(http.StatusOK, http.MethodDelete, "/api/v1/auth/sessions/"+otherID, nil, nil)
+	other.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/sessions", nil, nil)
+	h.expect(http.StatusOK, http.MethodGet, "/api/v1/auth/sessions", nil, &sessions)
+	if len(sessions) != 1 || sessions[0]["current"] != true {
+		t.Fatalf("GET /sessions after revoking = %v, want the current session", sessions)
+	}
+
+	// another user can not revoke the session
+	other.expect(http.StatusCreated, http.MethodPost, "/api/v1/auth/signup", map[string]string{"email": "intruder@example.com", "password": "secret1"}, nil)
+	other.expect(http.StatusNotFound, http.MethodDelete, "/api/v1/auth/sessions/"+sessions[0]["id"].(string), nil, nil)
+
+	other.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/login", credentials, nil)
+	h.expect(http.StatusOK, http.MethodPost, "/api/v1/auth/logout/all", nil, nil)
+	h.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/sessions", nil, nil)
+	other.expect(http.StatusUnauthorized, http.MethodGet, "/api/v1/auth/sessions", nil, nil)
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatalf("strconv.Atoi: %v", err)
+	}
+	return n
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	return u
+}
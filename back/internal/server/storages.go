@@ -0,0 +1,79 @@
+package server
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/auth"
+	authS "github.com/aakosarev/kanban-board/back/internal/auth/storage"
+	"github.com/aakosarev/kanban-board/back/internal/idempotency"
+	idempotencyS "github.com/aakosarev/kanban-board/back/internal/idempotency/storage"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	kanbanS "github.com/aakosarev/kanban-board/back/internal/kanban/storage"
+	"github.com/aakosarev/kanban-board/back/internal/ratelimit"
+	rateLimitS "github.com/aakosarev/kanban-board/back/internal/ratelimit/storage"
+	"github.com/aakosarev/kanban-board/back/internal/session"
+	sessionS "github.com/aakosarev/kanban-board/back/internal/session/storage"
+	"github.com/aakosarev/kanban-board/back/internal/template"
+	templateS "github.com/aakosarev/kanban-board/back/internal/template/storage"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	"github.com/aakosarev/kanban-board/back/pkg/memory"
+	"github.com/aakosarev/kanban-board/back/pkg/postgres"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
+)
+
+type storages struct {
+	txManager   transaction.Manager
+	session     session.Storage
+	auth        auth.Storage
+	authTokens  auth.TokenStorage
+	attempts    auth.AttemptStorage
+	kanban      kanban.Storage
+	history     kanban.HistoryStorage
+	boardCache  kanban.BoardCache
+	template    template.Storage
+	idempotency idempotency.Storage
+	rateLimit   ratelimit.Storage
+}
+
+// initStorages builds the storages for the configured driver. The memory driver needs
+// neither Postgres nor Redis and loses all data on restart.
+func (s *Server) initStorages() *storages {
+	if s.cfg.Storage.Driver == constants.Memory {
+		db := memory.NewDB()
+		st := &storages{
+			txManager:   memory.NewTxManager(db),
+			session:     sessionS.NewMemorySessionStorage(),
+			auth:        authS.NewMemoryAuthStorage(db),
+			authTokens:  authS.NewMemoryTokenStorage(),
+			attempts:    authS.NewMemoryAttemptStorage(),
+			kanban:      kanbanS.NewMemoryKanbanStorage(db),
+			history:     kanbanS.NewMemoryHistoryStorage(s.cfg),
+			template:    templateS.NewMemoryTemplateStorage(db),
+			idempotency: idempotencyS.NewMemoryIdempotencyStorage(),
+			rateLimit:   rateLimitS.NewMemoryRateLimitStorage(),
+		}
+
+		if s.cfg.Redis.BoardCache {
+			st.boardCache = kanbanS.NewMemoryBoardCache(s.cfg)
+		}
+
+		return st
+	}
+
+	st := &storages{
+		txManager:   postgres.NewTxManager(s.postgresClient),
+		session:     sessionS.NewSessionStorage(s.redisClient, s.cfg),
+		auth:        authS.NewAuthStorage(s.log, s.postgresClient),
+		authTokens:  authS.NewTokenStorage(s.redisClient),
+		attempts:    authS.NewAttemptStorage(s.redisClient),
+		kanban:      kanbanS.NewKanbanStorage(s.log, s.postgresClient),
+		history:     kanbanS.NewHistoryStorage(s.redisClient, s.cfg),
+		template:    templateS.NewTemplateStorage(s.log, s.postgresClient),
+		idempotency: idempotencyS.NewIdempotencyStorage(s.redisClient),
+		rateLimit:   rateLimitS.NewRateLimitStorage(s.redisClient),
+	}
+
+	if s.cfg.Redis.BoardCache {
+		st.boardCache = kanbanS.NewBoardCache(s.redisClient, s.cfg)
+	}
+
+	return st
+}
@@ -8,5 +8,14 @@ import (
 type Storage interface {
 	CreateSession(ctx context.Context, session *models.Session, expire int) (string, error)
 	GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error)
+	// GetByUserID returns the live sessions of a user, most recently seen first.
+	GetByUserID(ctx context.Context, userID int) ([]*models.Session, error)
+	// UpdateSession replaces a session without extending it and does nothing if the
+	// session is gone, so a revoked session can not come back.
+	UpdateSession(ctx context.Context, sessionID string, session *models.Session) error
 	DeleteByID(ctx context.Context, sessionID string) error
+	DeleteByUserID(ctx context.Context, userID int) error
+	// DeleteUserSession deletes the session with the given ID, unless it belongs to
+	// another user.
+	DeleteUserSession(ctx context.Context, userID int, id string) error
 }
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/session"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+type memorySession struct {
+	session  models.Session
+	expireAt time.Time
+}
+
+type memorySessionStorage struct {
+	mu         sync.Mutex
+	sessions   map[string]memorySession
+	basePrefix string
+}
+
+func NewMemorySessionStorage() session.Storage {
+	return &memorySessionStorage{sessions: make(map[string]memorySession), basePrefix: basePrefix}
+}
+
+func (s *memorySessionStorage) CreateSession(ctx context.Context, sess *models.Session, expire int) (string, error) {
+	sess.SessionID = uuid.New().String()
+	sess.ID = uuid.New().String()
+	sess.CreatedAt = time.Now().UTC()
+	sess.LastSeenAt = sess.CreatedAt
+	sessionKey := s.createKey(sess.SessionID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sessionKey] = memorySession{session: *sess, expireAt: time.Now().Add(time.Second * time.Duration(expire))}
+
+	return sessionKey, nil
+}
+
+// GetSessionByID reports missing and expired sessions with domainErrors.NotFound.
+func (s *memorySessionStorage) GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms, ok := s.sessions[sessionID]
+	if !ok || time.Now().After(ms.expireAt) {
+		delete(s.sessions, sessionID)
+		return nil, errors.Wrap(domainErrors.NotFound, "memorySessionStorage.GetSessionByID")
+	}
+
+	sess := ms.session
+	return &sess, nil
+}
+
+func (s *memorySessionStorage) GetByUserID(ctx context.Context, userID int) ([]*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]*models.Session, 0)
+	for key, ms := range s.sessions {
+		if time.Now().After(ms.expireAt) {
+			delete(s.sessions, key)
+			continue
+		}
+		if ms.session.UserID == userID {
+			sess := ms.session
+			sessions = append(sessions, &sess)
+		}
+	}
+
+	sortByLastSeen(sessions)
+	return sessions, nil
+}
+
+func (s *memorySessionStorage) UpdateSession(ctx context.Context, sessionID string, sess *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms, ok := s.sessions[sessionID]
+	if !ok || time.Now().After(ms.expireAt) {
+		return nil
+	}
+
+	ms.session = *sess
+	s.sessions[sessionID] = ms
+
+	return nil
+}
+
+func (s *memorySessionStorage) DeleteByID(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+
+	return nil
+}
+
+func (s *memorySessionStorage) DeleteByUserID(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, ms := range s.sessions {
+		if ms.session.UserID == userID {
+			delete(s.sessions, key)
+		}
+	}
+
+	return nil
+}
+
+func (s *memorySessionStorage) DeleteUserSession(ctx context.Context, userID int, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, ms := range s.sessions {
+		if ms.session.UserID == userID && ms.session.ID == id && !time.Now().After(ms.expireAt) {
+			delete(s.sessions, key)
+			return nil
+		}
+	}
+
+	return errors.Wrap(domainErrors.NotFound, "memorySessionStorage.DeleteUserSession")
+}
+
+func (s *memorySessionStorage) createKey(sessionID string) string {
+	return fmt.Sprintf("%s: %s", s.basePrefix, sessionID)
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/models"
 	"github.com/aakosarev/kanban-board/back/internal/session"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
+	"sort"
 	"time"
 )
 
@@ -29,14 +31,25 @@ func NewSessionStorage(redisClient *redis.Client, cfg *config.Config) session.St
 
 func (s *sessionStorage) CreateSession(ctx context.Context, sess *models.Session, expire int) (string, error) {
 	sess.SessionID = uuid.New().String()
+	sess.ID = uuid.New().String()
+	sess.CreatedAt = time.Now().UTC()
+	sess.LastSeenAt = sess.CreatedAt
 	sessionKey := s.createKey(sess.SessionID)
 
 	sessBytes, err := json.Marshal(&sess)
 	if err != nil {
 		return "", errors.WithMessage(err, "sessionStorage.CreateSession.json.Marshal")
 	}
-	if err = s.redisClient.Set(ctx, sessionKey, sessBytes, time.Second*time.Duration(expire)).Err(); err != nil {
-		return "", errors.Wrap(err, "sessionStorage.CreateSession.redisClient.Set")
+
+	// sessions share the configured lifetime, so extending the index with each new
+	// session keeps it alive as long as its latest session
+	userKey := s.createUserKey(sess.UserID)
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, sessionKey, sessBytes, time.Second*time.Duration(expire))
+	pipe.SAdd(ctx, userKey, sessionKey)
+	pipe.Expire(ctx, userKey, time.Second*time.Duration(expire))
+	if _, err = pipe.Exec(ctx); err != nil {
+		return "", errors.Wrap(err, "sessionStorage.CreateSession.pipe.Exec")
 	}
 	return sessionKey, nil
 }
@@ -44,6 +57,9 @@ func (s *sessionStorage) CreateSession(ctx context.Context, sess *models.Session
 func (s *sessionStorage) GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error) {
 	sessBytes, err := s.redisClient.Get(ctx, sessionID).Bytes()
 	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.Wrap(domainErrors.NotFound, "sessionStorage.GetSessionByID.redisClient.Get")
+		}
 		return nil, errors.Wrap(err, "sessionStorage.GetSessionByID.redisClient.Get")
 	}
 
@@ -54,13 +70,119 @@ func (s *sessionStorage) GetSessionByID(ctx context.Context, sessionID string) (
 	return sess, nil
 }
 
+// GetByUserID prunes the index of the user, which still holds the keys of sessions
+// that expired on their own.
+func (s *sessionStorage) GetByUserID(ctx context.Context, userID int) ([]*models.Session, error) {
+	userKey := s.createUserKey(userID)
+
+	sessionKeys, err := s.redisClient.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "sessionStorage.GetByUserID.redisClient.SMembers")
+	}
+
+	sessions := make([]*models.Session, 0, len(sessionKeys))
+	if len(sessionKeys) == 0 {
+		return sessions, nil
+	}
+
+	values, err := s.redisClient.MGet(ctx, sessionKeys...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "sessionStorage.GetByUserID.redisClient.MGet")
+	}
+
+	var expired []interface{}
+	for i, value := range values {
+		sessStr, ok := value.(string)
+		if !ok {
+			expired = append(expired, sessionKeys[i])
+			continue
+		}
+
+		sess := &models.Session{}
+		if err = json.Unmarshal([]byte(sessStr), sess); err != nil {
+			return nil, errors.Wrap(err, "sessionStorage.GetByUserID.json.Unmarshal")
+		}
+		sessions = append(sessions, sess)
+	}
+
+	if len(expired) > 0 {
+		if err = s.redisClient.SRem(ctx, userKey, expired...).Err(); err != nil {
+			return nil, errors.Wrap(err, "sessionStorage.GetByUserID.redisClient.SRem")
+		}
+	}
+
+	sortByLastSeen(sessions)
+	return sessions, nil
+}
+
+func (s *sessionStorage) UpdateSession(ctx context.Context, sessionID string, sess *models.Session) error {
+	sessBytes, err := json.Marshal(sess)
+	if err != nil {
+		return errors.Wrap(err, "sessionStorage.UpdateSession.json.Marshal")
+	}
+
+	if err = s.redisClient.SetXX(ctx, sessionID, sessBytes, redis.KeepTTL).Err(); err != nil {
+		return errors.Wrap(err, "sessionStorage.UpdateSession.redisClient.SetXX")
+	}
+	return nil
+}
+
 func (s *sessionStorage) DeleteByID(ctx context.Context, sessionID string) error {
-	if err := s.redisClient.Del(ctx, sessionID).Err(); err != nil {
-		return errors.Wrap(err, "sessionStorage.DeleteByID")
+	sess, err := s.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return nil
+		}
+		return err
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, sessionID)
+	pipe.SRem(ctx, s.createUserKey(sess.UserID), sessionID)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "sessionStorage.DeleteByID.pipe.Exec")
+	}
+	return nil
+}
+
+func (s *sessionStorage) DeleteByUserID(ctx context.Context, userID int) error {
+	userKey := s.createUserKey(userID)
+
+	sessionKeys, err := s.redisClient.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return errors.Wrap(err, "sessionStorage.DeleteByUserID.redisClient.SMembers")
+	}
+
+	if err = s.redisClient.Del(ctx, append(sessionKeys, userKey)...).Err(); err != nil {
+		return errors.Wrap(err, "sessionStorage.DeleteByUserID.redisClient.Del")
 	}
 	return nil
 }
 
+func (s *sessionStorage) DeleteUserSession(ctx context.Context, userID int, id string) error {
+	sessions, err := s.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, sess := range sessions {
+		if sess.ID == id {
+			return s.DeleteByID(ctx, s.createKey(sess.SessionID))
+		}
+	}
+	return errors.Wrap(domainErrors.NotFound, "sessionStorage.DeleteUserSession")
+}
+
 func (s *sessionStorage) createKey(sessionID string) string {
 	return fmt.Sprintf("%s: %s", s.basePrefix, sessionID)
 }
+
+func (s *sessionStorage) createUserKey(userID int) string {
+	return fmt.Sprintf("%s-user: %d", s.basePrefix, userID)
+}
+
+func sortByLastSeen(sessions []*models.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+}
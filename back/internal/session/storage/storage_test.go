@@ -0,0 +1,19 @@
+package storage_test
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	sessionS "github.com/aakosarev/kanban-board/back/internal/session/storage"
+	"github.com/aakosarev/kanban-board/back/internal/session/storage/storagetest"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	"github.com/aakosarev/kanban-board/back/pkg/redistest"
+	"testing"
+)
+
+func TestMemorySessionStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Fresh(sessionS.NewMemorySessionStorage))
+}
+
+// TestRedisSessionStorage runs only when REDIS_ADDR points to a Redis instance.
+func TestRedisSessionStorage(t *testing.T) {
+	storagetest.Run(t, conformance.Shared(sessionS.NewSessionStorage(redistest.Client(t), &config.Config{})))
+}
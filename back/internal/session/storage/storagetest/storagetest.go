@@ -0,0 +1,207 @@
+// Package storagetest is a conformance suite for session.Storage implementations.
+package storagetest
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/session"
+	"github.com/aakosarev/kanban-board/back/pkg/conformance"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+// Run runs the suite against the storage returned by newStorage, which is called
+// once per test.
+func Run(t *testing.T, newStorage func(t *testing.T) session.Storage) {
+	conformance.Run(t, newStorage, []conformance.Test[session.Storage]{
+		{Name: "CreateAndGet", Fn: testCreateAndGet},
+		{Name: "UniqueKeys", Fn: testUniqueKeys},
+		{Name: "Delete", Fn: testDelete},
+		{Name: "DeleteByUserID", Fn: testDeleteByUserID},
+		{Name: "GetByUserID", Fn: testGetByUserID},
+		{Name: "UpdateDeleted", Fn: testUpdateDeleted},
+		{Name: "DeleteUserSession", Fn: testDeleteUserSession},
+		{Name: "NotFound", Fn: testNotFound},
+		{Name: "Expiry", Fn: testExpiry},
+	}...)
+}
+
+func testCreateAndGet(ctx context.Context, t *testing.T, s session.Storage) {
+
+	key, err := s.CreateSession(ctx, &models.Session{UserID: 42}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	sess, err := s.GetSessionByID(ctx, key)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	if sess.UserID != 42 || sess.SessionID == "" {
+		t.Fatalf("GetSessionByID = %+v, want user 42 with a session ID", sess)
+	}
+}
+
+func testUniqueKeys(ctx context.Context, t *testing.T, s session.Storage) {
+
+	first, err := s.CreateSession(ctx, &models.Session{UserID: 1}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	second, err := s.CreateSession(ctx, &models.Session{UserID: 1}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if first == second {
+		t.Fatalf("CreateSession returned %q twice", first)
+	}
+}
+
+func testDelete(ctx context.Context, t *testing.T, s session.Storage) {
+
+	key, err := s.CreateSession(ctx, &models.Session{UserID: 1}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err = s.DeleteByID(ctx, key); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+	if _, err = s.GetSessionByID(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("GetSessionByID after delete err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testDeleteByUserID(ctx context.Context, t *testing.T, s session.Storage) {
+
+	var keys []string
+	for i := 0; i < 2; i++ {
+		key, err := s.CreateSession(ctx, &models.Session{UserID: 7}, 60)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	other, err := s.CreateSession(ctx, &models.Session{UserID: 8}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	if err = s.DeleteByUserID(ctx, 7); err != nil {
+		t.Fatalf("DeleteByUserID: %v", err)
+	}
+	for _, key := range keys {
+		if _, err = s.GetSessionByID(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+			t.Fatalf("GetSessionByID after DeleteByUserID err = %v, want domainErrors.NotFound", err)
+		}
+	}
+	if _, err = s.GetSessionByID(ctx, other); err != nil {
+		t.Fatalf("GetSessionByID of another user: %v", err)
+	}
+}
+
+func testGetByUserID(ctx context.Context, t *testing.T, s session.Storage) {
+
+	first, err := s.CreateSession(ctx, &models.Session{UserID: 3, IPAddress: "192.0.2.1", UserAgent: "first"}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if _, err = s.CreateSession(ctx, &models.Session{UserID: 3, UserAgent: "second"}, 60); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if _, err = s.CreateSession(ctx, &models.Session{UserID: 4}, 60); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// the first session was seen last
+	sess, err := s.GetSessionByID(ctx, first)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	sess.LastSeenAt = sess.LastSeenAt.Add(time.Minute)
+	if err = s.UpdateSession(ctx, first, sess); err != nil {
+		t.Fatalf("UpdateSession: %v", err)
+	}
+
+	sessions, err := s.GetByUserID(ctx, 3)
+	if err != nil {
+		t.Fatalf("GetByUserID: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].UserAgent != "first" || sessions[1].UserAgent != "second" {
+		t.Fatalf("GetByUserID = %+v, want the first and the second session", sessions)
+	}
+	if got := sessions[0]; got.ID == "" || got.ID == sessions[1].ID || got.IPAddress != "192.0.2.1" || got.CreatedAt.IsZero() {
+		t.Fatalf("GetByUserID returned %+v, want the stored metadata", got)
+	}
+
+	if sessions, err = s.GetByUserID(ctx, 5); err != nil || len(sessions) != 0 {
+		t.Fatalf("GetByUserID of a user without sessions = %v, %v", sessions, err)
+	}
+}
+
+func testUpdateDeleted(ctx context.Context, t *testing.T, s session.Storage) {
+
+	key, err := s.CreateSession(ctx, &models.Session{UserID: 1}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	sess, err := s.GetSessionByID(ctx, key)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	if err = s.DeleteByID(ctx, key); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+
+	if err = s.UpdateSession(ctx, key, sess); err != nil {
+		t.Fatalf("UpdateSession: %v", err)
+	}
+	if _, err = s.GetSessionByID(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("GetSessionByID after updating a deleted session err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testDeleteUserSession(ctx context.Context, t *testing.T, s session.Storage) {
+
+	key, err := s.CreateSession(ctx, &models.Session{UserID: 9}, 60)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	sess, err := s.GetSessionByID(ctx, key)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+
+	if err = s.DeleteUserSession(ctx, 10, sess.ID); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("DeleteUserSession of another user err = %v, want domainErrors.NotFound", err)
+	}
+	if err = s.DeleteUserSession(ctx, 9, sess.ID); err != nil {
+		t.Fatalf("DeleteUserSession: %v", err)
+	}
+	if _, err = s.GetSessionByID(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("GetSessionByID after DeleteUserSession err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testNotFound(ctx context.Context, t *testing.T, s session.Storage) {
+
+	if _, err := s.GetSessionByID(ctx, "api-session: missing"); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("GetSessionByID err = %v, want domainErrors.NotFound", err)
+	}
+}
+
+func testExpiry(ctx context.Context, t *testing.T, s session.Storage) {
+	t.Parallel()
+
+	key, err := s.CreateSession(ctx, &models.Session{UserID: 1}, 1)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, err = s.GetSessionByID(ctx, key); !errors.Is(err, domainErrors.NotFound) {
+		t.Fatalf("GetSessionByID after expiry err = %v, want domainErrors.NotFound", err)
+	}
+}
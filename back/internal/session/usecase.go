@@ -8,5 +8,9 @@ import (
 type UseCase interface {
 	CreateSession(ctx context.Context, session *models.Session, expire int) (string, error)
 	GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error)
+	GetByUserID(ctx context.Context, userID int, currentID string) ([]*models.Session, error)
+	Touch(ctx context.Context, sessionID string, session *models.Session, ip string) error
 	DeleteByID(ctx context.Context, sessionID string) error
+	DeleteByUserID(ctx context.Context, userID int) error
+	DeleteUserSession(ctx context.Context, userID int, id string) error
 }
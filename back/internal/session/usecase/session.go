@@ -5,6 +5,11 @@ import (
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/models"
 	"github.com/aakosarev/kanban-board/back/internal/session"
+	"time"
+)
+
+const (
+	touchInterval = time.Minute
 )
 
 type sessionUC struct {
@@ -27,3 +32,40 @@ func (u *sessionUC) DeleteByID(ctx context.Context, sessionID string) error {
 func (u *sessionUC) GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error) {
 	return u.sessionStorage.GetSessionByID(ctx, sessionID)
 }
+
+// GetByUserID marks the session with currentID as the current one.
+func (u *sessionUC) GetByUserID(ctx context.Context, userID int, currentID string) ([]*models.Session, error) {
+	sessions, err := u.sessionStorage.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sess := range sessions {
+		sess.Current = sess.ID == currentID
+		sess.SanitizeSessionID()
+	}
+
+	return sessions, nil
+}
+
+// Touch records that a session was used from ip. A session is written at most once per
+// touchInterval while its IP address stays the same, so not every request is a write.
+func (u *sessionUC) Touch(ctx context.Context, sessionID string, session *models.Session, ip string) error {
+	now := time.Now().UTC()
+	if session.IPAddress == ip && now.Sub(session.LastSeenAt) < touchInterval {
+		return nil
+	}
+
+	session.IPAddress = ip
+	session.LastSeenAt = now
+
+	return u.sessionStorage.UpdateSession(ctx, sessionID, session)
+}
+
+func (u *sessionUC) DeleteByUserID(ctx context.Context, userID int) error {
+	return u.sessionStorage.DeleteByUserID(ctx, userID)
+}
+
+func (u *sessionUC) DeleteUserSession(ctx context.Context, userID int, id string) error {
+	return u.sessionStorage.DeleteUserSession(ctx, userID, id)
+}
@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aakosarev/kanban-board/back/internal/models"
+)
+
+//go:embed templates/*.json
+var templatesFS embed.FS
+
+var templates = mustLoad()
+
+// All returns the built-in templates ordered by slug.
+func All() []*models.Template {
+	result := make([]*models.Template, 0, len(templates))
+	for _, t := range templates {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Slug < result[j].Slug })
+	return result
+}
+
+// BySlug returns the built-in template stored as templates/<slug>.json.
+func BySlug(slug string) (*models.Template, bool) {
+	t, ok := templates[slug]
+	return t, ok
+}
+
+func mustLoad() map[string]*models.Template {
+	entries, err := templatesFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	result := make(map[string]*models.Template, len(entries))
+	for _, entry := range entries {
+		data, err := templatesFS.ReadFile(path.Join("templates", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		t := &models.Template{}
+		if err = json.Unmarshal(data, t); err != nil {
+			panic(err)
+		}
+
+		t.Slug = strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		result[t.Slug] = t
+	}
+
+	return result
+}
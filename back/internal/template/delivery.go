@@ -0,0 +1,13 @@
+package template
+
+import "github.com/labstack/echo/v4"
+
+type Handlers interface {
+	SaveBoardAsTemplate() echo.HandlerFunc
+	GetTemplateByID() echo.HandlerFunc
+	GetTemplates() echo.HandlerFunc
+	DeleteTemplate() echo.HandlerFunc
+
+	CreateBoardFromTemplate() echo.HandlerFunc
+	CreateBoardFromBuiltinTemplate() echo.HandlerFunc
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/middleware"
+	"github.com/aakosarev/kanban-board/back/internal/template"
+	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/aakosarev/kanban-board/back/pkg/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type TemplateHandlers struct {
+	group      *echo.Group
+	mw         *middleware.Manager
+	log        logger.Logger
+	cfg        *config.Config
+	v          *validator.Validate
+	templateUC template.UseCase
+}
+
+func NewTemplateHandlers(
+	group *echo.Group,
+	mw *middleware.Manager,
+	log logger.Logger,
+	cfg *config.Config,
+	v *validator.Validate,
+	templateUC template.UseCase,
+) *TemplateHandlers {
+	return &TemplateHandlers{group: group, mw: mw, log: log, cfg: cfg, v: v, templateUC: templateUC}
+}
+
+func (h *TemplateHandlers) SaveBoardAsTemplate() echo.HandlerFunc {
+	type SaveTemplate struct {
+		Name         string `json:"name" validate:"required,lte=255"`
+		IncludeTasks bool   `json:"include_tasks"`
+	}
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		req := &SaveTemplate{}
+		if err := utils.ReadRequest(c, req); err != nil {
+			h.log.Errorf("(utils.ReadRequest) err: {%v}", err)
+			return err
+		}
+
+		createdTemplate, err := h.templateUC.SaveBoardAsTemplate(c.Request().Context(), user.ID, req.Name, req.IncludeTasks)
+		if err != nil {
+			h.log.Errorf("(templateUC.SaveBoardAsTemplate) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, createdTemplate)
+	}
+}
+
+func (h *TemplateHandlers) GetTemplateByID() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		templateIDStr := c.Param("template_id")
+		templateID, err := strconv.Atoi(templateIDStr)
+		if err != nil {
+			h.log.Errorf("(TemplateHandlers.GetTemplateByID.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
+		}
+
+		t, err := h.templateUC.GetTemplateByID(c.Request().Context(), user.ID, templateID)
+		if err != nil {
+			h.log.Errorf("(templateUC.GetTemplateByID) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, t)
+	}
+}
+
+func (h *TemplateHandlers) GetTemplates() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		templates, err := h.templateUC.GetTemplatesByUserID(c.Request().Context(), user.ID)
+		if err != nil {
+			h.log.Errorf("(templateUC.GetTemplatesByUserID) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusOK, templates)
+	}
+}
+
+func (h *TemplateHandlers) DeleteTemplate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		templateIDStr := c.Param("template_id")
+		templateID, err := strconv.Atoi(templateIDStr)
+		if err != nil {
+			h.log.Errorf("(TemplateHandlers.DeleteTemplate.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
+		}
+
+		if err = h.templateUC.DeleteTemplate(c.Request().Context(), user.ID, templateID); err != nil {
+			h.log.Errorf("(templateUC.DeleteTemplate) err: {%v}", err)
+			return err
+		}
+
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+func (h *TemplateHandlers) CreateBoardFromTemplate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		templateIDStr := c.Param("template_id")
+		templateID, err := strconv.Atoi(templateIDStr)
+		if err != nil {
+			h.log.Errorf("(TemplateHandlers.CreateBoardFromTemplate.Atoi) err: {%v}", err)
+			return httpErrors.BadRequest
+		}
+
+		board, err := h.templateUC.CreateBoardFromTemplate(c.Request().Context(), user.ID, templateID)
+		if err != nil {
+			h.log.Errorf("(templateUC.CreateBoardFromTemplate) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, board)
+	}
+}
+
+func (h *TemplateHandlers) CreateBoardFromBuiltinTemplate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := utils.GetUserFromCtx(c.Request().Context())
+		if err != nil {
+			h.log.Errorf("(utils.GetUserFromCtx) err: {%v}", err)
+			return err
+		}
+
+		board, err := h.templateUC.CreateBoardFromBuiltinTemplate(c.Request().Context(), user.ID, c.Param("slug"))
+		if err != nil {
+			h.log.Errorf("(templateUC.CreateBoardFromBuiltinTemplate) err: {%v}", err)
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, board)
+	}
+}
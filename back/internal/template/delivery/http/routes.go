@@ -0,0 +1,10 @@
+package http
+
+func (h *TemplateHandlers) MapRoutes() {
+	h.group.GET("", h.GetTemplates())
+	h.group.POST("/create", h.SaveBoardAsTemplate(), h.mw.IdempotencyMiddleware)
+	h.group.GET("/:template_id", h.GetTemplateByID())
+	h.group.DELETE("/:template_id", h.DeleteTemplate())
+	h.group.POST("/:template_id/create_board", h.CreateBoardFromTemplate(), h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+	h.group.POST("/builtin/:slug/create_board", h.CreateBoardFromBuiltinTemplate(), h.mw.VerifiedEmailMiddleware, h.mw.IdempotencyMiddleware)
+}
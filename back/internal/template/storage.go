@@ -0,0 +1,15 @@
+package template
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+)
+
+type Storage interface {
+	CreateTemplate(ctx context.Context, template *models.Template) (*models.Template, error)
+	GetTemplateByID(ctx context.Context, id int) (*models.Template, error)
+	GetTemplatesByUserID(ctx context.Context, userID int) ([]*models.Template, error)
+	DeleteTemplate(ctx context.Context, id int) error
+
+	CreateBoardFromTemplate(ctx context.Context, userID int, template *models.Template) (*models.Board, error)
+}
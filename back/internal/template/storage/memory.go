@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/template"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/memory"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+type memoryTemplateStorage struct {
+	db *memory.DB
+}
+
+func NewMemoryTemplateStorage(db *memory.DB) template.Storage {
+	return &memoryTemplateStorage{db: db}
+}
+
+func (s *memoryTemplateStorage) CreateTemplate(ctx context.Context, template *models.Template) (*models.Template, error) {
+	var t models.Template
+	err := s.db.Do(ctx, func() error {
+		if _, ok := s.db.Users[template.UserID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryTemplateStorage.CreateTemplate: user %d", template.UserID)
+		}
+		t = models.Template{ID: s.db.NextID(memory.TemplateTable), UserID: template.UserID, Name: template.Name, Columns: template.Columns}
+		s.db.Templates[t.ID] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *memoryTemplateStorage) GetTemplateByID(ctx context.Context, id int) (*models.Template, error) {
+	var t models.Template
+	err := s.db.Do(ctx, func() error {
+		var ok bool
+		if t, ok = s.db.Templates[id]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryTemplateStorage.GetTemplateByID")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *memoryTemplateStorage) GetTemplatesByUserID(ctx context.Context, userID int) ([]*models.Template, error) {
+	templates := make([]*models.Template, 0)
+	err := s.db.Do(ctx, func() error {
+		for _, t := range s.db.Templates {
+			if t.UserID == userID {
+				t := t
+				templates = append(templates, &t)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+
+	return templates, nil
+}
+
+func (s *memoryTemplateStorage) DeleteTemplate(ctx context.Context, id int) error {
+	return s.db.Do(ctx, func() error {
+		if _, ok := s.db.Templates[id]; !ok {
+			return errors.Wrap(domainErrors.NotFound, "memoryTemplateStorage.DeleteTemplate")
+		}
+		delete(s.db.Templates, id)
+		return nil
+	})
+}
+
+func (s *memoryTemplateStorage) CreateBoardFromTemplate(ctx context.Context, userID int, template *models.Template) (*models.Board, error) {
+	b := &models.Board{Columns: make([]*models.Col, 0, len(template.Columns))}
+	err := s.db.Do(ctx, func() error {
+		if _, ok := s.db.Users[userID]; !ok {
+			return errors.Wrapf(domainErrors.NotFound, "memoryTemplateStorage.CreateBoardFromTemplate: user %d", userID)
+		}
+
+		for _, column := range template.Columns {
+			c := models.Column{ID: s.db.NextID(memory.ColumnTable), UserID: userID, Name: column.Name}
+			s.db.Columns[c.ID] = c
+
+			col := &models.Col{ID: c.ID, Name: c.Name, Tasks: make([]*models.T, 0, len(column.Tasks))}
+			for _, description := range column.Tasks {
+				t := models.Task{ID: s.db.NextID(memory.TaskTable), ColumnID: c.ID, Description: description}
+				s.db.Tasks[t.ID] = t
+				col.Tasks = append(col.Tasks, &models.T{ID: t.ID, ColumnID: t.ColumnID, Description: t.Description})
+			}
+
+			b.Columns = append(b.Columns, col)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/template"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/aakosarev/kanban-board/back/pkg/postgres"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+type TemplateStorage struct {
+	log       logger.Logger
+	client    postgres.Client
+	txManager transaction.Manager
+}
+
+func NewTemplateStorage(log logger.Logger, client postgres.Client) template.Storage {
+	return &TemplateStorage{
+		log:       log,
+		client:    client,
+		txManager: postgres.NewTxManager(client),
+	}
+}
+
+func (s *TemplateStorage) CreateTemplate(ctx context.Context, template *models.Template) (*models.Template, error) {
+	query := `
+		INSERT INTO "template"(user_id, name, columns)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, name, columns;
+	`
+
+	t := &models.Template{}
+
+	if err := s.conn(ctx).QueryRow(ctx, query, template.UserID, template.Name, template.Columns).Scan(&t.ID, &t.UserID, &t.Name, &t.Columns); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *TemplateStorage) GetTemplateByID(ctx context.Context, id int) (*models.Template, error) {
+	query := `
+		SELECT id, user_id, name, columns
+		FROM "template"
+		WHERE id = $1;
+	`
+
+	t := &models.Template{}
+
+	if err := s.conn(ctx).QueryRow(ctx, query, id).Scan(&t.ID, &t.UserID, &t.Name, &t.Columns); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(domainErrors.NotFound, "TemplateStorage.GetTemplateByID.Scan")
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *TemplateStorage) GetTemplatesByUserID(ctx context.Context, userID int) ([]*models.Template, error) {
+	query := `
+		SELECT id, user_id, name, columns
+		FROM "template"
+		WHERE user_id = $1
+		ORDER BY id;
+	`
+
+	rows, err := s.conn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "TemplateStorage.GetTemplatesByUserID.Query")
+	}
+	defer rows.Close()
+
+	templates := make([]*models.Template, 0)
+	for rows.Next() {
+		t := &models.Template{}
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Columns); err != nil {
+			return nil, errors.Wrap(err, "TemplateStorage.GetTemplatesByUserID.Scan")
+		}
+		templates = append(templates, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "TemplateStorage.GetTemplatesByUserID.rows")
+	}
+
+	return templates, nil
+}
+
+func (s *TemplateStorage) DeleteTemplate(ctx context.Context, id int) error {
+	query := `
+		DELETE FROM "template"
+		WHERE id = $1;
+	`
+
+	res, err := s.conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return errors.Wrap(err, "TemplateStorage.DeleteTemplate.Exec")
+	}
+
+	if rowsAffected := res.RowsAffected(); rowsAffected == 0 {
+		return errors.Wrap(domainErrors.NotFound, "TemplateStorage.DeleteTemplate.rowsAffected")
+	}
+
+	return nil
+}
+
+// CreateBoardFromTemplate creates the template's columns and sample tasks on the user's board
+// in a single transaction and returns only the created part of the board.
+func (s *TemplateStorage) CreateBoardFromTemplate(ctx context.Context, userID int, template *models.Template) (*models.Board, error) {
+	columnQuery := `
+		INSERT INTO "column"(user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, name;
+	`
+	taskQuery := `
+		INSERT INTO "task"(column_id, description)
+		VALUES ($1, $2)
+		RETURNING id, column_id, description;
+	`
+
+	b := &models.Board{Columns: make([]*models.Col, 0, len(template.Columns))}
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, column := range template.Columns {
+			col := &models.Col{Tasks: make([]*models.T, 0, len(column.Tasks))}
+			if err := s.conn(ctx).QueryRow(ctx, columnQuery, userID, column.Name).Scan(&col.ID, &col.Name); err != nil {
+				return errors.Wrap(err, "TemplateStorage.CreateBoardFromTemplate.column")
+			}
+
+			for _, description := range column.Tasks {
+				t := &models.T{}
+				if err := s.conn(ctx).QueryRow(ctx, taskQuery, col.ID, description).Scan(&t.ID, &t.ColumnID, &t.Description); err != nil {
+					return errors.Wrap(err, "TemplateStorage.CreateBoardFromTemplate.task")
+				}
+				col.Tasks = append(col.Tasks, t)
+			}
+
+			b.Columns = append(b.Columns, col)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s *TemplateStorage) conn(ctx context.Context) postgres.Querier {
+	return postgres.Conn(ctx, s.client)
+}
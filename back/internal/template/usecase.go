@@ -0,0 +1,16 @@
+package template
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+)
+
+type UseCase interface {
+	SaveBoardAsTemplate(ctx context.Context, userID int, name string, includeTasks bool) (*models.Template, error)
+	GetTemplateByID(ctx context.Context, userID int, id int) (*models.Template, error)
+	GetTemplatesByUserID(ctx context.Context, userID int) ([]*models.Template, error)
+	DeleteTemplate(ctx context.Context, userID int, id int) error
+
+	CreateBoardFromTemplate(ctx context.Context, userID int, id int) (*models.Board, error)
+	CreateBoardFromBuiltinTemplate(ctx context.Context, userID int, slug string) (*models.Board, error)
+}
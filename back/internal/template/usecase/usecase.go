@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/internal/kanban"
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/internal/template"
+	"github.com/aakosarev/kanban-board/back/internal/template/builtin"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+type templateUseCase struct {
+	cfg             *config.Config
+	templateStorage template.Storage
+	kanbanStorage   kanban.Storage
+	historyStorage  kanban.HistoryStorage
+	boardCache      kanban.BoardCache
+	log             logger.Logger
+}
+
+func NewTemplateUseCase(
+	cfg *config.Config,
+	templateStorage template.Storage,
+	kanbanStorage kanban.Storage,
+	historyStorage kanban.HistoryStorage,
+	boardCache kanban.BoardCache,
+	log logger.Logger,
+) template.UseCase {
+	return &templateUseCase{cfg: cfg, templateStorage: templateStorage, kanbanStorage: kanbanStorage, historyStorage: historyStorage, boardCache: boardCache, log: log}
+}
+
+func (tuc *templateUseCase) SaveBoardAsTemplate(ctx context.Context, userID int, name string, includeTasks bool) (*models.Template, error) {
+	board, err := tuc.kanbanStorage.GetKanbanBoardByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &models.Template{
+		UserID:  userID,
+		Name:    name,
+		Columns: make([]*models.TemplateColumn, 0, len(board.Columns)),
+	}
+
+	for _, col := range board.Columns {
+		column := &models.TemplateColumn{Name: col.Name}
+		if includeTasks {
+			for _, task := range col.Tasks {
+				column.Tasks = append(column.Tasks, task.Description)
+			}
+		}
+		t.Columns = append(t.Columns, column)
+	}
+
+	return tuc.templateStorage.CreateTemplate(ctx, t)
+}
+
+func (tuc *templateUseCase) GetTemplateByID(ctx context.Context, userID int, id int) (*models.Template, error) {
+	t, err := tuc.templateStorage.GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// templates of other users are reported as missing
+	if t.UserID != userID {
+		return nil, errors.Wrap(domainErrors.NotFound, "templateUseCase.GetTemplateByID")
+	}
+
+	return t, nil
+}
+
+func (tuc *templateUseCase) GetTemplatesByUserID(ctx context.Context, userID int) ([]*models.Template, error) {
+	templates, err := tuc.templateStorage.GetTemplatesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(builtin.All(), templates...), nil
+}
+
+func (tuc *templateUseCase) DeleteTemplate(ctx context.Context, userID int, id int) error {
+	if _, err := tuc.GetTemplateByID(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return tuc.templateStorage.DeleteTemplate(ctx, id)
+}
+
+func (tuc *templateUseCase) CreateBoardFromTemplate(ctx context.Context, userID int, id int) (*models.Board, error) {
+	t, err := tuc.GetTemplateByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return tuc.createBoard(ctx, userID, t)
+}
+
+func (tuc *templateUseCase) CreateBoardFromBuiltinTemplate(ctx context.Context, userID int, slug string) (*models.Board, error) {
+	t, ok := builtin.BySlug(slug)
+	if !ok {
+		return nil, errors.Wrapf(domainErrors.NotFound, "templateUseCase.CreateBoardFromBuiltinTemplate: %s", slug)
+	}
+
+	return tuc.createBoard(ctx, userID, t)
+}
+
+func (tuc *templateUseCase) createBoard(ctx context.Context, userID int, t *models.Template) (*models.Board, error) {
+	board, err := tuc.templateStorage.CreateBoardFromTemplate(ctx, userID, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if tuc.boardCache != nil {
+		if err = tuc.boardCache.Invalidate(ctx, userID); err != nil {
+			tuc.log.WarnMsg("templateUseCase.createBoard.Invalidate", err)
+		}
+	}
+
+	tuc.record(ctx, userID, board)
+
+	return board, nil
+}
+
+// record puts the creation of board onto the user's undo stack as a single action, so
+// one undo removes everything the template created. Like kanbanUseCase.record it
+// clears the redo stack and never fails the board creation.
+func (tuc *templateUseCase) record(ctx context.Context, userID int, board *models.Board) {
+	if len(board.Columns) == 0 {
+		return
+	}
+
+	steps := make([]*models.HistoryStep, 0, len(board.Columns))
+	for _, col := range board.Columns {
+		steps = append(steps, &models.HistoryStep{ColumnAfter: &models.Column{ID: col.ID, UserID: userID, Name: col.Name}})
+		for _, t := range col.Tasks {
+			steps = append(steps, &models.HistoryStep{TaskAfter: &models.Task{ID: t.ID, ColumnID: t.ColumnID, Description: t.Description}})
+		}
+	}
+
+	if err := tuc.historyStorage.Push(ctx, userID, models.HistoryUndo, &models.HistoryAction{Steps: steps}); err != nil {
+		tuc.log.WarnMsg("templateUseCase.record.Push", err)
+		return
+	}
+
+	if err := tuc.historyStorage.Clear(ctx, userID, models.HistoryRedo); err != nil {
+		tuc.log.WarnMsg("templateUseCase.record.Clear", err)
+	}
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS column_user_id_idx ON "column"(user_id);
+CREATE INDEX IF NOT EXISTS task_column_id_idx ON "task"(column_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS task_column_id_idx;
+DROP INDEX IF EXISTS column_user_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS locale VARCHAR(8) NOT NULL DEFAULT 'en';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "user" DROP COLUMN IF EXISTS locale;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS "recovery_code" (
+    user_id INT NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "recovery_code";
+ALTER TABLE "user" DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE "user" DROP COLUMN IF EXISTS totp_secret;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+// Package conformance runs the storage conformance suites that live in the storagetest
+// packages.
+package conformance
+
+import (
+	"context"
+	"testing"
+)
+
+// Test is a named test that runs against a storage of type S.
+type Test[S any] struct {
+	Name string
+	Fn   func(ctx context.Context, t *testing.T, s S)
+}
+
+// Run runs every test as a subtest against the storage returned by newStorage, which is
+// called once per test.
+func Run[S any](t *testing.T, newStorage func(t *testing.T) S, tests ...Test[S]) {
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.Name, func(t *testing.T) {
+			tt.Fn(context.Background(), t, newStorage(t))
+		})
+	}
+}
+
+// Fresh returns a newStorage that builds a new storage with fn for every test. Use it
+// for storages that keep their state in memory.
+func Fresh[S any](fn func() S) func(t *testing.T) S {
+	return func(t *testing.T) S {
+		return fn()
+	}
+}
+
+// Shared returns a newStorage that hands the same storage to every test. Use it for
+// storages backed by a server, where the tests keep apart by using unique keys.
+func Shared[S any](s S) func(t *testing.T) S {
+	return func(t *testing.T) S {
+		return s
+	}
+}
@@ -17,8 +17,11 @@ const (
 	Redis         = "redis"
 	Kafka         = "kafka"
 	Postgres      = "postgres"
+	Memory        = "memory"
 	MongoDB       = "mongo"
 	ElasticSearch = "elasticSearch"
+	SMTP          = "smtp"
+	Outbox        = "outbox"
 
 	GRPC     = "GRPC"
 	SIZE     = "SIZE"
@@ -46,6 +49,15 @@ const (
 	CreatedDate  = "CreatedDate"
 	UserMetadata = "UserMetadata"
 
+	IdempotencyKey     = "Idempotency-Key"
+	IdempotentReplayed = "Idempotent-Replayed"
+	AcceptLanguage     = "Accept-Language"
+	ContentLanguage    = "Content-Language"
+	RateLimitLimit     = "RateLimit-Limit"
+	RateLimitRemaining = "RateLimit-Remaining"
+	RateLimitReset     = "RateLimit-Reset"
+	RateLimitPolicy    = "RateLimit-Policy"
+
 	Page   = "page"
 	Size   = "size"
 	Search = "search"
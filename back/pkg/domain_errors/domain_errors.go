@@ -0,0 +1,72 @@
+package domainErrors
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// Storages and use cases report failures by wrapping one of these errors, so the
+// delivery layer can tell them apart with errors.Is.
+var (
+	NotFound        = errors.New("not found")
+	Conflict        = errors.New("conflict")
+	Forbidden       = errors.New("forbidden")
+	Unauthorized    = errors.New("unauthorized")
+	Validation      = errors.New("validation failed")
+	Unprocessable   = errors.New("unprocessable")
+	TooManyRequests = errors.New("too many requests")
+)
+
+// FieldError describes why a single field of a request is invalid. Rule and Param
+// are the failed validation tag and its parameter, when there is one. Errors without
+// a Message get one from the message catalog of the rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is a Validation error that carries the invalid fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) error {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	causes := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		cause := f.Message
+		if cause == "" {
+			cause = strings.TrimSpace(f.Rule + " " + f.Param)
+		}
+		causes = append(causes, fmt.Sprintf("%s: %s", f.Field, cause))
+	}
+	return fmt.Sprintf("%s: %s", Validation.Error(), strings.Join(causes, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == Validation
+}
+
+// TooManyRequestsError is a TooManyRequests error that tells when to retry.
+type TooManyRequestsError struct {
+	RetryAfter time.Duration
+}
+
+func NewTooManyRequestsError(retryAfter time.Duration) error {
+	return &TooManyRequestsError{RetryAfter: retryAfter}
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", TooManyRequests.Error(), e.RetryAfter)
+}
+
+func (e *TooManyRequestsError) Is(target error) bool {
+	return target == TooManyRequests
+}
@@ -2,239 +2,177 @@ package httpErrors
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
-	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/aakosarev/kanban-board/back/pkg/validation"
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+	"math"
 	"net/http"
-	"strings"
-	"time"
-
-	"github.com/go-playground/validator"
+	"path"
+	"strconv"
 )
 
+// ProblemContentType is the media type of error responses, see RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem types. They are relative URI references, resolved against the request URI.
+// The last segment of a type is the catalog key of its title.
 const (
-	ErrBadRequest          = "Bad request"
-	ErrNotFound            = "Not Found"
-	ErrUnauthorized        = "Unauthorized"
-	ErrRequestTimeout      = "Request Timeout"
-	ErrInvalidEmail        = "Invalid email"
-	ErrInvalidPassword     = "Invalid password"
-	ErrInvalidField        = "Invalid field"
-	ErrInternalServerError = "Internal Server Error"
-	ErrEmailAlreadyExists  = "User with given email already exists"
+	TypeBadRequest       = "/problems/bad-request"
+	TypeValidation       = "/problems/validation"
+	TypeNotFound         = "/problems/not-found"
+	TypeUnauthorized     = "/problems/unauthorized"
+	TypeForbidden        = "/problems/forbidden"
+	TypeConflict         = "/problems/conflict"
+	TypeUnprocessable    = "/problems/unprocessable"
+	TypeUnsupportedMedia = "/problems/unsupported-media-type"
+	TypeTooManyRequests  = "/problems/too-many-requests"
+	TypeRequestTimeout   = "/problems/request-timeout"
+	TypeInternal         = "/problems/internal"
+	TypeHTTP             = "about:blank"
 )
 
+// Errors of the HTTP layer itself. Storages and use cases use domainErrors instead.
 var (
 	BadRequest          = errors.New("Bad request")
-	WrongCredentials    = errors.New("Wrong Credentials")
-	NotFound            = errors.New("Not Found")
-	Unauthorized        = errors.New("Unauthorized")
-	Forbidden           = errors.New("Forbidden")
+	UnsupportedMedia    = errors.New("Unsupported Media Type")
 	InternalServerError = errors.New("Internal Server Error")
 )
 
-// RestErr Rest error interface
-type RestErr interface {
-	Status() int
-	Error() string
-	Causes() interface{}
-	ErrBody() RestError
-}
-
-// RestError Rest error struct
-type RestError struct {
-	ErrStatus  int         `json:"status,omitempty"`
-	ErrError   string      `json:"error,omitempty"`
-	ErrMessage interface{} `json:"message,omitempty"`
-	Timestamp  time.Time   `json:"timestamp,omitempty"`
+// Problem is an application/problem+json response body.
+type Problem struct {
+	Type     string                    `json:"type"`
+	Title    string                    `json:"title"`
+	Status   int                       `json:"status"`
+	Detail   string                    `json:"detail,omitempty"`
+	Instance string                    `json:"instance,omitempty"`
+	Errors   []domainErrors.FieldError `json:"errors,omitempty"`
 }
 
-// ErrBody Error body
-func (e RestError) ErrBody() RestError {
-	return e
+func (p *Problem) Error() string {
+	return fmt.Sprintf("status: %d - type: %s - title: %s - detail: %s", p.Status, p.Type, p.Title, p.Detail)
 }
 
-// Error  Error() interface method
-func (e RestError) Error() string {
-	return fmt.Sprintf("status: %d - errors: %s - causes: %v", e.ErrStatus, e.ErrError, e.ErrMessage)
-}
-
-// Status Error status
-func (e RestError) Status() int {
-	return e.ErrStatus
-}
-
-// Causes RestError Causes
-func (e RestError) Causes() interface{} {
-	return e.ErrMessage
-}
-
-// NewRestError New Rest Error
-func NewRestError(status int, err string, causes interface{}, debug bool) RestErr {
-	restError := RestError{
-		ErrStatus: status,
-		ErrError:  err,
-		Timestamp: time.Now().UTC(),
-	}
+// NewProblem creates a problem with a title in the given locale. The detail is only
+// kept when debug is set, as it may describe internals of the service.
+func NewProblem(problemType string, status int, detail string, locale string, debug bool) *Problem {
+	p := &Problem{Type: problemType, Title: title(problemType, status, locale), Status: status}
 	if debug {
-		restError.ErrMessage = causes
+		p.Detail = detail
 	}
-	return restError
+	return p
 }
 
-// NewRestErrorWithMessage New Rest Error With Message
-func NewRestErrorWithMessage(status int, err string, causes interface{}) RestErr {
-	return RestError{
-		ErrStatus:  status,
-		ErrError:   err,
-		ErrMessage: causes,
-		Timestamp:  time.Now().UTC(),
+func title(problemType string, status int, locale string) string {
+	if problemType != TypeHTTP {
+		return i18n.T(locale, "problem."+path.Base(problemType))
 	}
-}
-
-// NewRestErrorFromBytes New Rest Error From Bytes
-func NewRestErrorFromBytes(bytes []byte) (RestErr, error) {
-	var apiErr RestError
-	if err := json.Unmarshal(bytes, &apiErr); err != nil {
-		return nil, errors.New("invalid json")
+	if key := fmt.Sprintf("problem.status.%d", status); i18n.Has(key) {
+		return i18n.T(locale, key)
 	}
-	return apiErr, nil
+	return http.StatusText(status)
 }
 
-// NewBadRequestError New Bad Request Error
-func NewBadRequestError(ctx echo.Context, causes interface{}, debug bool) error {
-	restError := RestError{
-		ErrStatus: http.StatusBadRequest,
-		ErrError:  BadRequest.Error(),
-		Timestamp: time.Now().UTC(),
-	}
-	if debug {
-		restError.ErrMessage = causes
-	}
-	return ctx.JSON(http.StatusBadRequest, restError)
-}
-
-// NewNotFoundError New Not Found Error
-func NewNotFoundError(ctx echo.Context, causes interface{}, debug bool) error {
-	restError := RestError{
-		ErrStatus: http.StatusNotFound,
-		ErrError:  NotFound.Error(),
-		Timestamp: time.Now().UTC(),
-	}
-	if debug {
-		restError.ErrMessage = causes
+// localizeFields fills in the messages of field errors that only carry a rule, such as
+// the ones created by use cases.
+func localizeFields(fields []domainErrors.FieldError, locale string) []domainErrors.FieldError {
+	localized := make([]domainErrors.FieldError, len(fields))
+	for i, f := range fields {
+		if f.Message == "" {
+			if f.Param != "" {
+				f.Message = i18n.T(locale, "validation."+f.Rule, f.Field, f.Param)
+			} else {
+				f.Message = i18n.T(locale, "validation."+f.Rule, f.Field)
+			}
+		}
+		localized[i] = f
 	}
-	return ctx.JSON(http.StatusNotFound, restError)
+	return localized
 }
 
-// NewUnauthorizedError New Unauthorized Error
-func NewUnauthorizedError(ctx echo.Context, causes interface{}, debug bool) error {
+// ParseErrors maps an error to the problem it should produce, with messages in the
+// given locale. Classification only relies on the error chain, never on the error text.
+func ParseErrors(err error, locale string, debug bool) *Problem {
+	var (
+		validationErr  *domainErrors.ValidationError
+		validationErrs validator.ValidationErrors
+		httpErr        *echo.HTTPError
+	)
 
-	restError := RestError{
-		ErrStatus: http.StatusUnauthorized,
-		ErrError:  Unauthorized.Error(),
-		Timestamp: time.Now().UTC(),
-	}
-	if debug {
-		restError.ErrMessage = causes
+	switch {
+	case errors.As(err, &validationErrs):
+		p := NewProblem(TypeValidation, http.StatusBadRequest, err.Error(), locale, debug)
+		p.Errors = validation.FieldErrors(validationErrs, locale)
+		return p
+	case errors.As(err, &validationErr):
+		p := NewProblem(TypeValidation, http.StatusBadRequest, err.Error(), locale, debug)
+		p.Errors = localizeFields(validationErr.Fields, locale)
+		return p
+	case errors.Is(err, domainErrors.Validation), errors.Is(err, BadRequest):
+		return NewProblem(TypeBadRequest, http.StatusBadRequest, err.Error(), locale, debug)
+	case errors.Is(err, domainErrors.NotFound):
+		return NewProblem(TypeNotFound, http.StatusNotFound, err.Error(), locale, debug)
+	case errors.Is(err, domainErrors.Unauthorized):
+		return NewProblem(TypeUnauthorized, http.StatusUnauthorized, err.Error(), locale, debug)
+	case errors.Is(err, domainErrors.Forbidden):
+		return NewProblem(TypeForbidden, http.StatusForbidden, err.Error(), locale, debug)
+	case errors.Is(err, domainErrors.Conflict):
+		return NewProblem(TypeConflict, http.StatusConflict, err.Error(), locale, debug)
+	case errors.Is(err, domainErrors.Unprocessable):
+		return NewProblem(TypeUnprocessable, http.StatusUnprocessableEntity, err.Error(), locale, debug)
+	case errors.Is(err, domainErrors.TooManyRequests):
+		return NewProblem(TypeTooManyRequests, http.StatusTooManyRequests, err.Error(), locale, debug)
+	case errors.Is(err, UnsupportedMedia):
+		return NewProblem(TypeUnsupportedMedia, http.StatusUnsupportedMediaType, err.Error(), locale, debug)
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewProblem(TypeRequestTimeout, http.StatusRequestTimeout, err.Error(), locale, debug)
+	case errors.As(err, &httpErr):
+		return NewProblem(TypeHTTP, httpErr.Code, httpErr.Error(), locale, debug)
+	default:
+		return NewProblem(TypeInternal, http.StatusInternalServerError, err.Error(), locale, debug)
 	}
-	return ctx.JSON(http.StatusUnauthorized, restError)
 }
 
-// NewForbiddenError New Forbidden Error
-func NewForbiddenError(ctx echo.Context, causes interface{}, debug bool) error {
-
-	restError := RestError{
-		ErrStatus: http.StatusForbidden,
-		ErrError:  Forbidden.Error(),
-		Timestamp: time.Now().UTC(),
-	}
-	if debug {
-		restError.ErrMessage = causes
-	}
-	return ctx.JSON(http.StatusForbidden, restError)
-}
+// NewHTTPErrorHandler returns the echo.HTTPErrorHandler that writes every error
+// returned by handlers and middlewares as a problem. The request ID is used as the
+// problem instance.
+func NewHTTPErrorHandler(log logger.Logger, debug bool) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
 
-// NewInternalServerError New Internal Server Error
-func NewInternalServerError(ctx echo.Context, causes interface{}, debug bool) error {
+		p := ParseErrors(err, i18n.FromContext(c.Request().Context()), debug)
+		p.Instance = c.Response().Header().Get(echo.HeaderXRequestID)
 
-	restError := RestError{
-		ErrStatus: http.StatusInternalServerError,
-		ErrError:  InternalServerError.Error(),
-		Timestamp: time.Now().UTC(),
-	}
-	if debug {
-		restError.ErrMessage = causes
-	}
-	return ctx.JSON(http.StatusInternalServerError, restError)
-}
+		var tooManyRequests *domainErrors.TooManyRequestsError
+		if errors.As(err, &tooManyRequests) {
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(tooManyRequests.RetryAfter.Seconds()))))
+		}
+		if p.Status >= http.StatusInternalServerError {
+			log.Errorf("(HTTPErrorHandler) RequestID: %s, err: {%v}", p.Instance, err)
+		}
 
-// ParseErrors Parser of error string messages returns RestError
-func ParseErrors(err error, debug bool) RestErr {
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		return NewRestError(http.StatusNotFound, ErrNotFound, err.Error(), debug)
-	case errors.Is(err, context.DeadlineExceeded):
-		return NewRestError(http.StatusRequestTimeout, ErrRequestTimeout, err.Error(), debug)
-	case errors.Is(err, Unauthorized):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err.Error(), debug)
-	case errors.Is(err, WrongCredentials):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.SQLState):
-		return parseSqlErrors(err, debug)
-	case strings.Contains(strings.ToLower(err.Error()), "field validation"):
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			return NewRestError(http.StatusBadRequest, ErrBadRequest, validationErrors.Error(), debug)
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(p.Status)
+		} else {
+			err = writeProblem(c, p)
 		}
-		return parseValidatorError(err, debug)
-	case strings.Contains(strings.ToLower(err.Error()), "required header"):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.Base64):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.Unmarshal):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.Uuid):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.Cookie):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.Token):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err.Error(), debug)
-	case strings.Contains(strings.ToLower(err.Error()), constants.Bcrypt):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err.Error(), debug)
-	default:
-		if restErr, ok := err.(*RestError); ok {
-			return restErr
+		if err != nil {
+			log.Warnf("(HTTPErrorHandler) write response err: {%v}", err)
 		}
-		return NewRestError(http.StatusInternalServerError, ErrInternalServerError, errors.Cause(err).Error(), debug)
 	}
 }
 
-func parseSqlErrors(err error, debug bool) RestErr {
-	return NewRestError(http.StatusBadRequest, ErrBadRequest, err, debug)
-}
-
-func parseValidatorError(err error, debug bool) RestErr {
-	if strings.Contains(err.Error(), "Password") {
-		return NewRestError(http.StatusBadRequest, ErrInvalidPassword, err, debug)
-	}
-
-	if strings.Contains(err.Error(), "Email") {
-		return NewRestError(http.StatusBadRequest, ErrInvalidEmail, err, debug)
+func writeProblem(c echo.Context, p *Problem) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
 	}
-
-	return NewRestError(http.StatusBadRequest, ErrInvalidField, err, debug)
-}
-
-// ErrorResponse Error response
-func ErrorResponse(err error, debug bool) (int, interface{}) {
-	return ParseErrors(err, debug).Status(), ParseErrors(err, debug)
-}
-
-// ErrorCtxResponse Error response object and status code
-func ErrorCtxResponse(ctx echo.Context, err error, debug bool) error {
-	restErr := ParseErrors(err, debug)
-	return ctx.JSON(restErr.Status(), restErr)
+	return c.Blob(p.Status, ProblemContentType, b)
 }
@@ -0,0 +1,99 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/text/language"
+	"path"
+)
+
+const (
+	English = "en"
+	Russian = "ru"
+
+	Default = English
+)
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+var (
+	// catalogs maps a locale to its messages. Messages are fmt format strings.
+	catalogs = map[string]map[string]string{}
+
+	// supported lists the locales in the order the matcher prefers them; the first one
+	// is used when nothing matches.
+	supported = []string{English, Russian}
+	matcher   language.Matcher
+)
+
+func init() {
+	tags := make([]language.Tag, 0, len(supported))
+	for _, locale := range supported {
+		tags = append(tags, language.MustParse(locale))
+
+		b, err := catalogFS.ReadFile(path.Join("catalogs", locale+".json"))
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err = json.Unmarshal(b, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: catalog %s: %v", locale, err))
+		}
+		catalogs[locale] = messages
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// Supported reports whether there is a catalog for locale.
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language header.
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, i, _ := matcher.Match(tags...)
+	return supported[i]
+}
+
+// T returns the message for key in locale, formatted with args. Messages missing
+// from the catalog fall back to the default locale, then to the key itself.
+func T(locale string, key string, args ...interface{}) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		if message, ok = catalogs[Default][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Has reports whether the default catalog has a message for key.
+func Has(key string) bool {
+	_, ok := catalogs[Default][key]
+	return ok
+}
+
+type localeCtxKey struct{}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeCtxKey{}, locale)
+}
+
+// FromContext returns the locale of the request, or the default one.
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeCtxKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default
+}
@@ -0,0 +1,62 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsHaveTheSameKeys(t *testing.T) {
+	for locale, messages := range catalogs {
+		for key, message := range catalogs[Default] {
+			translated, ok := messages[key]
+			if !ok {
+				t.Errorf("%s: missing %s", locale, key)
+				continue
+			}
+			if strings.Count(translated, "%") != strings.Count(message, "%") {
+				t.Errorf("%s: %s has other arguments than in %s", locale, key, Default)
+			}
+		}
+		for key := range messages {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s: %s is missing from %s", locale, key, Default)
+			}
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                          English,
+		"ru":                        Russian,
+		"ru-RU,ru;q=0.9,en;q=0.8":   Russian,
+		"en-US,en;q=0.9,ru;q=0.8":   English,
+		"de-DE,ru;q=0.5":            Russian,
+		"fr":                        English,
+		"not a language header ;;;": English,
+	}
+	for header, want := range tests {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(Russian, "validation.immutable", "id"); got != "id нельзя изменить" {
+		t.Errorf("T(ru) = %q", got)
+	}
+	if got := T("de", "validation.immutable", "id"); got != "id can not be changed" {
+		t.Errorf("T(de) = %q, want the default locale", got)
+	}
+	if got := T(English, "no.such.key"); got != "no.such.key" {
+		t.Errorf("T(missing key) = %q", got)
+	}
+	if got := FromContext(WithLocale(context.Background(), Russian)); got != Russian {
+		t.Errorf("FromContext = %s", got)
+	}
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext without a locale = %s", got)
+	}
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer returns the mailer of the configured driver.
+func NewMailer(cfg *config.Config, log logger.Logger) Mailer {
+	if cfg.Mail.Driver == constants.SMTP {
+		return NewSMTPMailer(cfg)
+	}
+	return NewOutboxMailer(cfg, log)
+}
+
+// Bytes formats the message as RFC 5322 text with CRLF line endings.
+func (m *Message) Bytes(from string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutboxMailer(t *testing.T) {
+	log := logger.NewAppLogger(&logger.Config{LogLevel: "fatal", Encoder: "console"})
+	log.InitLogger()
+
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m := NewOutboxMailer(&config.Config{Mail: config.Mail{From: "no-reply@kanban.local", OutboxDir: dir}}, log)
+
+	msg := &Message{To: "user@kanban.local", Subject: "Сброс пароля", Body: "first\nsecond"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("outbox has %v (err %v), want one message", files, err)
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	for _, want := range []string{
+		"From: no-reply@kanban.local\r\n",
+		"To: user@kanban.local\r\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nfirst\r\nsecond",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("message does not contain %q:\n%s", want, b)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/aakosarev/kanban-board/back/pkg/logger"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type outboxMailer struct {
+	cfg *config.Config
+	log logger.Logger
+}
+
+// NewOutboxMailer returns a mailer that never sends anything. Messages are written
+// to the outbox directory as .eml files, or logged when no directory is configured.
+func NewOutboxMailer(cfg *config.Config, log logger.Logger) Mailer {
+	return &outboxMailer{cfg: cfg, log: log}
+}
+
+func (m *outboxMailer) Send(ctx context.Context, msg *Message) error {
+	if m.cfg.Mail.OutboxDir == "" {
+		m.log.Infof("(outboxMailer) to: %s, subject: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.cfg.Mail.OutboxDir, 0o755); err != nil {
+		return errors.Wrap(err, "outboxMailer.Send.os.MkdirAll")
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("/", "_", "\\", "_").Replace(msg.To))
+	if err := os.WriteFile(filepath.Join(m.cfg.Mail.OutboxDir, name), msg.Bytes(m.cfg.Mail.From), 0o644); err != nil {
+		return errors.Wrap(err, "outboxMailer.Send.os.WriteFile")
+	}
+
+	m.log.Infof("(outboxMailer) to: %s, subject: %s, file: %s", msg.To, msg.Subject, name)
+	return nil
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aakosarev/kanban-board/back/internal/config"
+	"github.com/pkg/errors"
+	"net/smtp"
+)
+
+type smtpMailer struct {
+	cfg *config.Config
+}
+
+func NewSMTPMailer(cfg *config.Config) Mailer {
+	return &smtpMailer{cfg: cfg}
+}
+
+// Send authenticates only when a username is configured. net/smtp upgrades the
+// connection with STARTTLS when the server offers it.
+func (m *smtpMailer) Send(ctx context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if m.cfg.Mail.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.cfg.Mail.SMTPUsername, m.cfg.Mail.SMTPPassword, m.cfg.Mail.SMTPHost)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.cfg.Mail.SMTPHost, m.cfg.Mail.SMTPPort)
+	if err := smtp.SendMail(addr, auth, m.cfg.Mail.From, []string{msg.To}, msg.Bytes(m.cfg.Mail.From)); err != nil {
+		return errors.Wrap(err, "smtpMailer.Send.smtp.SendMail")
+	}
+	return nil
+}
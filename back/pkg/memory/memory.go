@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aakosarev/kanban-board/back/internal/models"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
+)
+
+// Table names used as sequence keys.
+const (
+	UserTable     = "user"
+	ColumnTable   = "column"
+	TaskTable     = "task"
+	TemplateTable = "template"
+)
+
+// DB is an in-memory replacement for the Postgres schema. Rows are stored by value,
+// so storages must replace a row instead of mutating it, which keeps snapshots cheap.
+type DB struct {
+	mu        sync.Mutex
+	sequences map[string]int
+
+	Users         map[int]models.User
+	RecoveryCodes map[RecoveryCode]struct{}
+	Columns       map[int]models.Column
+	Tasks         map[int]models.Task
+	Templates     map[int]models.Template
+}
+
+// RecoveryCode is a row of the recovery_code table.
+type RecoveryCode struct {
+	UserID   int
+	CodeHash string
+}
+
+func NewDB() *DB {
+	return &DB{
+		sequences:     make(map[string]int),
+		Users:         make(map[int]models.User),
+		RecoveryCodes: make(map[RecoveryCode]struct{}),
+		Columns:       make(map[int]models.Column),
+		Tasks:         make(map[int]models.Task),
+		Templates:     make(map[int]models.Template),
+	}
+}
+
+type txCtxKey struct{}
+
+// Do runs fn with exclusive access to the tables. Inside a transaction the lock is
+// already held by WithinTransaction.
+func (db *DB) Do(ctx context.Context, fn func() error) error {
+	if tx, ok := ctx.Value(txCtxKey{}).(*DB); ok && tx == db {
+		return fn()
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return fn()
+}
+
+// NextID works like a SERIAL column: IDs are never reused, even after a rollback.
+func (db *DB) NextID(table string) int {
+	db.sequences[table]++
+	return db.sequences[table]
+}
+
+// Reserve moves the sequence past an explicitly inserted ID.
+func (db *DB) Reserve(table string, id int) {
+	if db.sequences[table] < id {
+		db.sequences[table] = id
+	}
+}
+
+type snapshot struct {
+	users         map[int]models.User
+	recoveryCodes map[RecoveryCode]struct{}
+	columns       map[int]models.Column
+	tasks         map[int]models.Task
+	templates     map[int]models.Template
+}
+
+func (db *DB) snapshot() *snapshot {
+	return &snapshot{
+		users:         copyMap(db.Users),
+		recoveryCodes: copyMap(db.RecoveryCodes),
+		columns:       copyMap(db.Columns),
+		tasks:         copyMap(db.Tasks),
+		templates:     copyMap(db.Templates),
+	}
+}
+
+func (db *DB) restore(s *snapshot) {
+	db.Users = s.users
+	db.RecoveryCodes = s.recoveryCodes
+	db.Columns = s.columns
+	db.Tasks = s.tasks
+	db.Templates = s.templates
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+type txManager struct {
+	db *DB
+}
+
+func NewTxManager(db *DB) transaction.Manager {
+	return &txManager{db: db}
+}
+
+// WithinTransaction serializes transactions and rolls the tables back to their state
+// before fn when it fails. A nested call rolls back only its own changes.
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txCtxKey{}).(*DB); !ok || tx != m.db {
+		m.db.mu.Lock()
+		defer m.db.mu.Unlock()
+		ctx = context.WithValue(ctx, txCtxKey{}, m.db)
+	}
+
+	s := m.db.snapshot()
+	if err := fn(ctx); err != nil {
+		m.db.restore(s)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,326 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents.
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// ErrInvalidPatch is returned for malformed patches and patches that can not be applied.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// Apply applies a patch of the given content type to the JSON document doc.
+func Apply(contentType string, doc []byte, patch []byte) ([]byte, error) {
+	switch contentType {
+	case MergePatchContentType:
+		return MergePatch(doc, patch)
+	case JSONPatchContentType:
+		return JSONPatch(doc, patch)
+	}
+	return nil, errors.Errorf("unsupported patch content type %q", contentType)
+}
+
+// MergePatch applies an RFC 7396 merge patch to doc.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, errors.Wrap(err, "patch.MergePatch: document")
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, errors.Wrapf(ErrInvalidPatch, "merge patch: %v", err)
+	}
+
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = mergePatch(t[name], value)
+	}
+
+	return t
+}
+
+type operation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 patch to doc. The operations are applied in order and
+// the patch fails as a whole if any of them fails.
+func JSONPatch(doc []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, errors.Wrap(err, "patch.JSONPatch: document")
+	}
+
+	var ops []operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, errors.Wrapf(ErrInvalidPatch, "json patch: %v", err)
+	}
+
+	for i, op := range ops {
+		var err error
+		if target, err = applyOperation(target, op); err != nil {
+			return nil, errors.Wrapf(ErrInvalidPatch, "operation %d (%s): %v", i, op.Op, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc interface{}, op operation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, errors.New("missing path")
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		var value interface{}
+		if err = json.Unmarshal(*op.Value, &value); err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, errors.Errorf("value at %q does not match", *op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		if op.From == nil {
+			return nil, errors.New("missing from")
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("can not move a value into itself")
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, path, value)
+	}
+
+	return nil, errors.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("invalid pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, errors.Errorf("member %q does not exist", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, errors.Errorf("can not reference %q in a scalar", token)
+		}
+	}
+	return doc, nil
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[token] = value
+		return doc, nil
+	case []interface{}:
+		i := len(node)
+		if token != "-" {
+			if i, err = arrayIndex(token, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+		return replaceParent(doc, path[:len(path)-1], node)
+	}
+
+	return nil, errors.Errorf("can not add %q to a scalar", token)
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("can not remove the whole document")
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[token]; !ok {
+			return nil, errors.Errorf("member %q does not exist", token)
+		}
+		delete(node, token)
+		return doc, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node[:i], node[i+1:]...)
+		return replaceParent(doc, path[:len(path)-1], node)
+	}
+
+	return nil, errors.Errorf("can not remove %q from a scalar", token)
+}
+
+// replaceParent stores a resized array back into its parent, since appending may
+// have allocated a new slice.
+func replaceParent(doc interface{}, path []string, array []interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return array, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[token] = array
+	case []interface{}:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = array
+	}
+
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func isPrefix(prefix []string, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			c[k] = deepCopy(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = deepCopy(item)
+		}
+		return c
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+	}
+
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s): %v", tt.doc, tt.patch, err)
+			continue
+		}
+		assertJSON(t, got, tt.want)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"qux"}]`, `{"baz":"qux"}`},
+		{`{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+	}
+
+	for _, tt := range tests {
+		got, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("JSONPatch(%s, %s): %v", tt.doc, tt.patch, err)
+			continue
+		}
+		assertJSON(t, got, tt.want)
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		doc, patch string
+	}{
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`},
+		{`{"foo":[1]}`, `[{"op":"add","path":"/foo/5","value":1}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"jump","path":"/foo"}]`},
+		{`{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`},
+		{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`},
+	}
+
+	for _, tt := range tests {
+		if _, err := JSONPatch([]byte(tt.doc), []byte(tt.patch)); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("JSONPatch(%s, %s) err = %v, want ErrInvalidPatch", tt.doc, tt.patch, err)
+		}
+	}
+}
+
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("json.Unmarshal(%s): %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("json.Unmarshal(%s): %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	stringDataRightTruncation = "22001"
+	notNullViolation          = "23502"
+	foreignKeyViolation       = "23503"
+	uniqueViolation           = "23505"
+	checkViolation            = "23514"
+)
+
+// ParseError translates integrity constraint violations reported by Postgres into
+// domain errors. Other errors are returned unchanged.
+func ParseError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case uniqueViolation:
+		return errors.Wrapf(domainErrors.Conflict, "%s violates %s", pgErr.TableName, pgErr.ConstraintName)
+	case foreignKeyViolation:
+		// the same code is used both for a row referencing a missing one and for
+		// deleting a row that is still referenced
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return errors.Wrapf(domainErrors.Unprocessable, "%s is still referenced by %s", pgErr.TableName, pgErr.ConstraintName)
+		}
+		return errors.Wrapf(domainErrors.NotFound, "%s references a missing row by %s", pgErr.TableName, pgErr.ConstraintName)
+	case checkViolation, notNullViolation:
+		return errors.Wrapf(domainErrors.Validation, "%s violates %s", pgErr.TableName, firstNonEmpty(pgErr.ConstraintName, pgErr.ColumnName))
+	case stringDataRightTruncation:
+		return errors.Wrap(domainErrors.Validation, "value is too long")
+	}
+
+	return err
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// querier passes every error through ParseError, so storages get domain errors for
+// constraint violations without checking each statement themselves.
+type querier struct {
+	Querier
+}
+
+func (q querier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return row{q.Querier.QueryRow(ctx, sql, args...)}
+}
+
+func (q querier) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := q.Querier.Exec(ctx, sql, arguments...)
+	return tag, ParseError(err)
+}
+
+func (q querier) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	r, err := q.Querier.Query(ctx, query, args...)
+	if err != nil {
+		return nil, ParseError(err)
+	}
+	return rows{r}, nil
+}
+
+type row struct {
+	pgx.Row
+}
+
+func (r row) Scan(dest ...any) error {
+	return ParseError(r.Row.Scan(dest...))
+}
+
+type rows struct {
+	pgx.Rows
+}
+
+func (r rows) Err() error {
+	return ParseError(r.Rows.Err())
+}
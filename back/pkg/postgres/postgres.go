@@ -10,14 +10,33 @@ import (
 )
 
 type Client interface {
-	Begin(context.Context) (pgx.Tx, error)
+	Querier
 	BeginFunc(ctx context.Context, f func(pgx.Tx) error) error
 	BeginTxFunc(ctx context.Context, txOptions pgx.TxOptions, f func(pgx.Tx) error) error
+	Close()
+}
+
+// Querier is implemented by both the connection pool and pgx.Tx, so storages can run
+// the same statements inside and outside a transaction.
+type Querier interface {
+	Begin(context.Context) (pgx.Tx, error)
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
 }
 
+type client struct {
+	*pgxpool.Pool
+}
+
+func (c *client) BeginFunc(ctx context.Context, f func(pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, c.Pool, f)
+}
+
+func (c *client) BeginTxFunc(ctx context.Context, txOptions pgx.TxOptions, f func(pgx.Tx) error) error {
+	return pgx.BeginTxFunc(ctx, c.Pool, txOptions, f)
+}
+
 type PgConfig struct {
 	Username string
 	Password string
@@ -36,14 +55,16 @@ func NewPgConfig(username string, password string, host string, port string, dat
 	}
 }
 
-func NewClient(ctx context.Context, maxAttempts int, maxDelay time.Duration, cfg *PgConfig) (pool *pgxpool.Pool, err error) {
+func NewClient(ctx context.Context, maxAttempts int, maxDelay time.Duration, cfg *PgConfig) (Client, error) {
 	dsn := fmt.Sprintf(
 		"postgresql://%s:%s@%s:%s/%s?sslmode=disable",
 		cfg.Username, cfg.Password,
 		cfg.Host, cfg.Port, cfg.Database,
 	)
 
-	err = DoWithAttempts(func() error {
+	var pool *pgxpool.Pool
+
+	err := DoWithAttempts(func() error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
@@ -64,7 +85,7 @@ func NewClient(ctx context.Context, maxAttempts int, maxDelay time.Duration, cfg
 		return nil, err
 	}
 
-	return pool, nil
+	return &client{Pool: pool}, nil
 }
 
 func DoWithAttempts(fn func() error, maxAttempts int, delay time.Duration) error {
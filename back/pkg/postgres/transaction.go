@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/pkg/transaction"
+	"github.com/jackc/pgx/v5"
+)
+
+type txCtxKey struct{}
+
+type txManager struct {
+	client Client
+}
+
+func NewTxManager(client Client) transaction.Manager {
+	return &txManager{client: client}
+}
+
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	run := func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txCtxKey{}, tx))
+	}
+
+	// a nested transaction becomes a savepoint of the outer one
+	if tx, ok := ctx.Value(txCtxKey{}).(pgx.Tx); ok {
+		return pgx.BeginFunc(ctx, tx, run)
+	}
+
+	return m.client.BeginFunc(ctx, run)
+}
+
+// Conn returns the transaction started by a TxManager for ctx, or the client
+// when there is none. Constraint violations are reported as domain errors.
+func Conn(ctx context.Context, client Client) Querier {
+	if tx, ok := ctx.Value(txCtxKey{}).(pgx.Tx); ok {
+		return querier{tx}
+	}
+	return querier{client}
+}
@@ -0,0 +1,30 @@
+// Package redistest connects tests to the Redis instance REDIS_ADDR points to.
+package redistest
+
+import (
+	"context"
+	"github.com/aakosarev/kanban-board/back/pkg/constants"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"testing"
+)
+
+// Client returns a client for REDIS_ADDR that is closed when the test finishes. It skips
+// the test when REDIS_ADDR is not set and fails it when Redis does not answer.
+func Client(tb testing.TB) *redis.Client {
+	tb.Helper()
+
+	addr := os.Getenv(constants.RedisAddr)
+	if addr == "" {
+		tb.Skipf("%s is not set", constants.RedisAddr)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	tb.Cleanup(func() { client.Close() })
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		tb.Fatalf("redis.Ping %s: %v", addr, err)
+	}
+
+	return client
+}
@@ -0,0 +1,113 @@
+// Package totp implements time-based one-time passwords, see RFC 6238, with the
+// parameters authenticator apps support: HMAC-SHA1, 6 digits and 30 second steps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6
+	Period = 30 * time.Second
+
+	// Skew is how many steps before and after the current one are accepted, to allow
+	// for clock drift and for codes typed near the end of a step.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random secret encoded as unpadded base32, the form
+// authenticator apps expect.
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "totp.NewSecret.rand.Read")
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth URI apps import the secret from, usually as a QR code.
+func URI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// Code returns the code of the secret at the given time.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.Wrap(err, "totp.Code.DecodeString")
+	}
+	return hotp(key, uint64(t.Unix())/uint64(Period.Seconds()), Digits), nil
+}
+
+// Validate reports whether code is the code of the secret at the given time, or at
+// most Skew steps away from it, and returns the step of the code. Steps up to after
+// are not accepted: a verifier must not accept a code again once it has been used,
+// see RFC 6238, section 5.2.
+func Validate(secret string, code string, t time.Time, after int64) (int64, bool, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false, errors.Wrap(err, "totp.Validate.DecodeString")
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+
+	step := int64(t.Unix()) / int64(Period.Seconds())
+	for i := -Skew; i <= Skew; i++ {
+		if step+int64(i) <= after {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step+int64(i)), Digits)), []byte(code)) == 1 {
+			return step + int64(i), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// hotp is the HOTP algorithm of RFC 4226.
+func hotp(key []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,83 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA1 test vectors of RFC 6238, appendix B.
+func TestHOTPVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for unix, want := range tests {
+		if got := hotp(key, uint64(unix/30), 8); got != want {
+			t.Errorf("hotp at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		code string
+		want bool
+	}{
+		{now, code, true},
+		{now.Add(-Period), code, true},
+		{now.Add(Period), code, true},
+		{now.Add(2 * Period), code, false},
+		{now, code[:3] + " " + code[3:], true},
+		{now, code[:5], false},
+		{now, "abcdef", false},
+	}
+	for _, tt := range tests {
+		_, ok, err := Validate(strings.ToLower(secret), tt.code, tt.at, 0)
+		if err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		if ok != tt.want {
+			t.Errorf("Validate(%q) at %v = %v, want %v", tt.code, tt.at.Sub(now), ok, tt.want)
+		}
+	}
+
+	step, ok, err := Validate(secret, code, now.Add(Period), 0)
+	if err != nil || !ok || step != now.Unix()/30 {
+		t.Fatalf("Validate = %d, %v, %v, want the step of the code %d", step, ok, err, now.Unix()/30)
+	}
+	for _, after := range []int64{step, step + 1} {
+		if _, ok, err = Validate(secret, code, now, after); err != nil || ok {
+			t.Errorf("Validate after step %d = %v, %v, want the used code rejected", after-step, ok, err)
+		}
+	}
+	if _, ok, err = Validate(secret, code, now, step-1); err != nil || !ok {
+		t.Errorf("Validate after the previous step = %v, %v, want true", ok, err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Kanban board", "user@example.com", "JBSWY3DPEHPK3PXP")
+	for _, want := range []string{"otpauth://totp/Kanban%20board:user@example.com?", "secret=JBSWY3DPEHPK3PXP", "issuer=Kanban+board", "digits=6", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("URI = %s, want it to contain %s", uri, want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package transaction
+
+import "context"
+
+// Manager runs fn in a transaction. Storages pick the transaction up from the context
+// passed to fn, so every storage call made with that context is committed or rolled
+// back together. Nested calls join the outer transaction.
+type Manager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
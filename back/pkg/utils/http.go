@@ -4,7 +4,7 @@ import (
 	"context"
 	"github.com/aakosarev/kanban-board/back/internal/config"
 	"github.com/aakosarev/kanban-board/back/internal/models"
-	httpErrors "github.com/aakosarev/kanban-board/back/pkg/http_errors"
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"time"
@@ -53,19 +53,31 @@ type UserCtxKey struct{}
 func GetUserFromCtx(ctx context.Context) (*models.User, error) {
 	user, ok := ctx.Value(UserCtxKey{}).(*models.User)
 	if !ok {
-		return nil, httpErrors.Unauthorized
+		return nil, domainErrors.Unauthorized
 	}
 
 	return user, nil
 }
 
+type SessionCtxKey struct{}
+
+func GetSessionFromCtx(ctx context.Context) (*models.Session, error) {
+	sess, ok := ctx.Value(SessionCtxKey{}).(*models.Session)
+	if !ok {
+		return nil, domainErrors.Unauthorized
+	}
+
+	return sess, nil
+}
+
 func ReadRequest(ctx echo.Context, request interface{}) error {
 	if err := ctx.Bind(request); err != nil {
 		return err
 	}
-	return validate.StructCtx(ctx.Request().Context(), request)
+	return ValidateStruct(ctx.Request().Context(), request)
 }
 
+// GetIPAddress returns the client IP address found by the IP extractor of the server.
 func GetIPAddress(c echo.Context) string {
-	return c.Request().RemoteAddr
+	return c.RealIP()
 }
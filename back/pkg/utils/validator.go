@@ -2,16 +2,11 @@ package utils
 
 import (
 	"context"
-
-	"github.com/go-playground/validator/v10"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/aakosarev/kanban-board/back/pkg/validation"
 )
 
-var validate *validator.Validate
-
-func init() {
-	validate = validator.New()
-}
-
+// ValidateStruct validates s, reporting invalid fields in the locale of ctx.
 func ValidateStruct(ctx context.Context, s interface{}) error {
-	return validate.StructCtx(ctx, s)
+	return validation.Translate(validation.Validator().StructCtx(ctx, s), i18n.FromContext(ctx))
 }
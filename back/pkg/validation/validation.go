@@ -0,0 +1,84 @@
+package validation
+
+import (
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+)
+
+var (
+	validate   *validator.Validate
+	translator *ut.UniversalTranslator
+)
+
+func init() {
+	validate = validator.New()
+	validate.RegisterTagNameFunc(jsonName)
+
+	translator = ut.New(en.New(), en.New(), ru.New())
+
+	enTrans, _ := translator.GetTranslator(i18n.English)
+	if err := enTranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		panic(err)
+	}
+	ruTrans, _ := translator.GetTranslator(i18n.Russian)
+	if err := ruTranslations.RegisterDefaultTranslations(validate, ruTrans); err != nil {
+		panic(err)
+	}
+}
+
+// Validator returns the validator shared by the whole service. It reports fields by
+// their JSON names.
+func Validator() *validator.Validate {
+	return validate
+}
+
+// Translate turns validator.ValidationErrors into a domainErrors.ValidationError with
+// messages in the given locale. Other errors are returned unchanged.
+func Translate(err error, locale string) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+	return domainErrors.NewValidationError(FieldErrors(validationErrs, locale)...)
+}
+
+func FieldErrors(validationErrs validator.ValidationErrors, locale string) []domainErrors.FieldError {
+	trans, _ := translator.GetTranslator(locale)
+
+	fields := make([]domainErrors.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, domainErrors.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields
+}
+
+// fieldPath drops the name of the validated struct, so a field of a nested struct is
+// reported as "operations[0].op".
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	domainErrors "github.com/aakosarev/kanban-board/back/pkg/domain_errors"
+	"github.com/aakosarev/kanban-board/back/pkg/i18n"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+)
+
+type operation struct {
+	Op string `json:"op" validate:"required,oneof=create delete"`
+}
+
+type batch struct {
+	Name       string      `json:"name,omitempty" validate:"lte=3"`
+	Operations []operation `json:"operations" validate:"dive"`
+	Internal   int         `validate:"gte=0"`
+}
+
+func TestTranslate(t *testing.T) {
+	err := Validator().Struct(&batch{Name: "long", Operations: []operation{{Op: "create"}, {Op: "move"}}, Internal: -1})
+
+	want := []domainErrors.FieldError{
+		{Field: "name", Rule: "lte", Param: "3"},
+		{Field: "operations[1].op", Rule: "oneof", Param: "create delete"},
+		{Field: "Internal", Rule: "gte", Param: "0"},
+	}
+
+	messages := map[string][]string{}
+	for _, locale := range []string{i18n.English, i18n.Russian} {
+		var validationErr *domainErrors.ValidationError
+		if !errors.As(Translate(err, locale), &validationErr) {
+			t.Fatalf("Translate(%s) did not return a ValidationError", locale)
+		}
+
+		for i, field := range validationErr.Fields {
+			if field.Message == "" {
+				t.Errorf("Translate(%s): %s has no message", locale, field.Field)
+			}
+			messages[locale] = append(messages[locale], field.Message)
+			validationErr.Fields[i].Message = ""
+		}
+		if !reflect.DeepEqual(validationErr.Fields, want) {
+			t.Errorf("Translate(%s) = %+v, want %+v", locale, validationErr.Fields, want)
+		}
+	}
+
+	if reflect.DeepEqual(messages[i18n.English], messages[i18n.Russian]) {
+		t.Errorf("messages are not localized: %v", messages[i18n.English])
+	}
+}
+
+func TestTranslateOtherErrors(t *testing.T) {
+	if err := Translate(nil, i18n.English); err != nil {
+		t.Errorf("Translate(nil) = %v", err)
+	}
+	other := errors.New("other")
+	if err := Translate(other, i18n.English); err != other {
+		t.Errorf("Translate(other) = %v", err)
+	}
+}
@@ -1,8 +1,17 @@
 import './App.css'
+import {useCallback, useState} from "react";
 import KanbanBoard from "./components/KanbanBoard.tsx";
+import Login from "./components/Login.tsx";
 
 function App() {
-  return <KanbanBoard />;
+  // the session cookie is httpOnly, so assume it is there until the api says otherwise
+  const [authenticated, setAuthenticated] = useState(true);
+  const onUnauthorized = useCallback(() => setAuthenticated(false), []);
+
+  if (!authenticated) {
+    return <Login onLogin={() => setAuthenticated(true)} />;
+  }
+  return <KanbanBoard onUnauthorized={onUnauthorized} />;
 }
 
 export default App
@@ -18,7 +18,15 @@ import {arrayMove, SortableContext} from "@dnd-kit/sortable";
 import {createPortal} from "react-dom";
 import TaskCard from "./TaskCard.tsx";
 
-function KanbanBoard() {
+const mergePatch = {
+    headers: {'Content-Type': 'application/merge-patch+json'},
+};
+
+interface Props {
+    onUnauthorized: () => void;
+}
+
+function KanbanBoard({onUnauthorized}: Props) {
     const [columns, setColumns] = useState<Column[]>([]);
     const columnsId = useMemo(() => columns.map(col => col.id), [columns]);
 
@@ -37,7 +45,7 @@ function KanbanBoard() {
 
     useEffect(() => {
         const fetchInitialData = async () => {
-            await axios.get('http://localhost:5007/api/v1/board/1')
+            await axios.get('http://localhost:5007/api/v1/board')
                 .then((response) => {
                     if (response.status === 200) {
                         console.log(response.data.columns)
@@ -65,11 +73,15 @@ function KanbanBoard() {
                     }
                 })
                 .catch((error) => {
+                    if (error.response?.status === 401) {
+                        onUnauthorized();
+                        return;
+                    }
                     console.error('Ошибка при отправке запроса:', error);
                 });
         };
         fetchInitialData();
-    }, []);
+    }, [onUnauthorized]);
 
     return (
         <div
@@ -197,7 +209,7 @@ function KanbanBoard() {
         const requestData = {
             description: content,
         };
-        axios.patch(`http://localhost:5007/api/v1/task/${id}/update_description`, requestData)
+        axios.patch(`http://localhost:5007/api/v1/task/${id}`, requestData, mergePatch)
             .then((response) => {
                 if (response.status === 200) {
                     const newTasks = tasks.map(task => {
@@ -225,10 +237,9 @@ function KanbanBoard() {
         setTasks(newTasks);
     }
 
-    function createNewColumn(){ //TODO принимать user_id...или сделать user_id глобально
+    function createNewColumn(){
         const  name = `Столбец ${columns.length + 1}`
         const requestData = {
-            user_id: 1, //TODO hadrcode!!!
             name: name,
         };
         axios.post('http://localhost:5007/api/v1/column/create', requestData)
@@ -269,10 +280,9 @@ function KanbanBoard() {
 
     function updateColumn(id:Id, title: string) {
         const requestData = {
-            user_id: 1, //TODO hadrcode!!!
             name: title,
         };
-        axios.patch(`http://localhost:5007/api/v1/column/${id}/update_name`, requestData)
+        axios.patch(`http://localhost:5007/api/v1/column/${id}`, requestData, mergePatch)
             .then((response) => {
                 if (response.status === 200) {
                     const newColumns = columns.map((col) => {
@@ -363,7 +373,7 @@ function KanbanBoard() {
             const requestData = {
                 column_id: overId,
             };
-            axios.patch(`http://localhost:5007/api/v1/task/${activeId}/update_column_id`, requestData)
+            axios.post(`http://localhost:5007/api/v1/task/${activeId}/move`, requestData)
                 .then((response) => {
                     if (response.status !== 200) {
                         console.error('Неправильный статус ответа:', response.status);
@@ -0,0 +1,104 @@
+import {FormEvent, useState} from "react";
+import axios from 'axios';
+
+interface Props {
+    onLogin: () => void;
+}
+
+function Login({onLogin}: Props) {
+    const [email, setEmail] = useState("");
+    const [password, setPassword] = useState("");
+    const [mfaToken, setMfaToken] = useState<string | null>(null);
+    const [code, setCode] = useState("");
+    const [error, setError] = useState<string | null>(null);
+
+    const fail = (error: any) => {
+        console.error('Ошибка при отправке запроса:', error);
+        setError(error.response?.data?.title ?? 'Не удалось войти');
+    };
+
+    const login = (e: FormEvent) => {
+        e.preventDefault();
+        setError(null);
+
+        if (mfaToken !== null) {
+            axios.post('http://localhost:5007/api/v1/auth/login/totp', {mfa_token: mfaToken, code})
+                .then(() => onLogin())
+                .catch(fail);
+            return;
+        }
+
+        axios.post('http://localhost:5007/api/v1/auth/login', {email, password})
+            .then((response) => {
+                // 202 means the account has TOTP enabled and the code is still needed
+                if (response.status === 202) {
+                    setMfaToken(response.data.mfa_token);
+                    return;
+                }
+                onLogin();
+            })
+            .catch(fail);
+    };
+
+    const signup = () => {
+        setError(null);
+        axios.post('http://localhost:5007/api/v1/auth/signup', {email, password})
+            .then(() => onLogin())
+            .catch(fail);
+    };
+
+    return (
+        <div className="m-auto flex min-h-screen w-full items-center justify-center">
+            <form
+                onSubmit={login}
+                className="bg-columnBackgroundColor w-[350px] rounded-md flex flex-col gap-4 p-6"
+            >
+                {mfaToken === null ? (
+                    <>
+                        <input
+                            className="bg-black focus:border-rose-500 border rounded outline-none px-2 py-1"
+                            type="email"
+                            placeholder="Email"
+                            autoFocus
+                            value={email}
+                            onChange={(e) => setEmail(e.target.value)}
+                        />
+                        <input
+                            className="bg-black focus:border-rose-500 border rounded outline-none px-2 py-1"
+                            type="password"
+                            placeholder="Пароль"
+                            value={password}
+                            onChange={(e) => setPassword(e.target.value)}
+                        />
+                    </>
+                ) : (
+                    <input
+                        className="bg-black focus:border-rose-500 border rounded outline-none px-2 py-1"
+                        placeholder="Код из приложения"
+                        autoFocus
+                        value={code}
+                        onChange={(e) => setCode(e.target.value)}
+                    />
+                )}
+                {error && <p className="text-rose-500 text-sm">{error}</p>}
+                <button
+                    type="submit"
+                    className="bg-mainBackgroundColor border-2 border-columnBackgroundColor rounded-md p-2 hover:ring-2 hover:ring-rose-500"
+                >
+                    Войти
+                </button>
+                {mfaToken === null && (
+                    <button
+                        type="button"
+                        onClick={signup}
+                        className="bg-mainBackgroundColor border-2 border-columnBackgroundColor rounded-md p-2 hover:ring-2 hover:ring-rose-500"
+                    >
+                        Зарегистрироваться
+                    </button>
+                )}
+            </form>
+        </div>
+    );
+}
+
+export default Login
@@ -1,8 +1,12 @@
 import React from 'react'
 import ReactDOM from 'react-dom/client'
+import axios from 'axios'
 import App from './App.tsx'
 import './index.css'
 
+// the api authenticates by the session cookie, which it only gets cross-origin with credentials
+axios.defaults.withCredentials = true
+
 ReactDOM.createRoot(document.getElementById('root')!).render(
   <React.StrictMode>
     <App />